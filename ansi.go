@@ -0,0 +1,81 @@
+package core
+
+// states of an ansiStripper
+const (
+	ansiText = iota
+	// after an ESC
+	ansiEscape
+	// inside a CSI sequence, after ESC [
+	ansiCSI
+)
+
+// ansiStripper removes the ANSI escape sequences, like the SGR colors
+// ESC [ 31 m, from a stream. A sequence may span several writes, so the
+// state is carried over between calls
+type ansiStripper struct {
+	state int
+}
+
+// WithStripANSI removes the ANSI escape sequences, colors included, from
+// the records before they reach the file, so the output meant for a
+// terminal can also be logged to a file
+func WithStripANSI(strip bool) Option {
+	return func(l *FileLogger) {
+		if strip {
+			l.stripANSI = &ansiStripper{}
+		} else {
+			l.stripANSI = nil
+		}
+	}
+}
+
+// return p without the escape sequences, p itself is returned if it has
+// none
+func (s *ansiStripper) strip(p []byte) []byte {
+	var out []byte
+	for i, c := range p {
+		if s.state == ansiText && c != 0x1b {
+			if out != nil {
+				out = append(out, c)
+			}
+			continue
+		}
+		//from the first byte of a sequence on, the kept bytes are copied
+		if out == nil {
+			out = make([]byte, i, len(p))
+			copy(out, p[:i])
+		}
+		switch s.state {
+		case ansiText:
+			s.state = ansiEscape
+		case ansiEscape:
+			switch {
+			case c == '[':
+				s.state = ansiCSI
+			case c >= 0x30 && c <= 0x7e:
+				//a two bytes sequence like ESC c or ESC 7
+				s.state = ansiText
+			case c == 0x1b:
+			default:
+				//not a sequence, keep the byte
+				s.state = ansiText
+				out = append(out, c)
+			}
+		case ansiCSI:
+			switch {
+			case c >= 0x20 && c <= 0x3f:
+				//parameter and intermediate bytes
+			case c >= 0x40 && c <= 0x7e:
+				s.state = ansiText
+			default:
+				//a broken sequence ends at the first byte it can't have
+				s.state = ansiText
+				out = append(out, c)
+			}
+		}
+	}
+	if out == nil {
+		return p
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"plain", []string{"no color\n"}, "no color\n"},
+		{"color", []string{"\x1b[31mred\x1b[0m\n"}, "red\n"},
+		{"multiple parameters", []string{"\x1b[1;32;40mbold\x1b[m\n"}, "bold\n"},
+		{"two bytes sequence", []string{"\x1bcreset\n"}, "reset\n"},
+		{"split after ESC", []string{"a\x1b", "[31mb\n"}, "ab\n"},
+		{"split in the parameters", []string{"a\x1b[3", "1mb\n"}, "ab\n"},
+		{"split on every byte", []string{"a", "\x1b", "[", "1", ";", "3", "1", "m", "b\n"}, "ab\n"},
+		{"not a sequence", []string{"a\x1b!b\n"}, "a!b\n"},
+		{"broken sequence", []string{"a\x1b[31\nb\n"}, "a\nb\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLogger(t, 0, 3, WithStripANSI(true))
+			for _, w := range tt.writes {
+				if _, err := l.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, _ := l.ReadLog(0, 0); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripANSIUnchanged(t *testing.T) {
+	s := &ansiStripper{}
+	p := []byte("no escape\n")
+	if out := s.strip(p); &out[0] != &p[0] {
+		t.Error("a record without escape sequences is copied")
+	}
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLoggerClosed is returned when writing to a logger that has been closed
+var ErrLoggerClosed = errors.New("logger is closed")
+
+// AsyncLogger queues the writes and forwards them to an underlying Logger
+// from a single goroutine, so the caller never waits for the disk.
+//
+// By default Write blocks while the queue is full (BlockWhenFull); with
+// WithFullPolicy(DropWhenFull) it drops the record instead and counts it in
+// Dropped. Callers can also pick per record with WriteNonBlocking and
+// Pressure. With WithAsyncFlushInterval the records are gathered and
+// forwarded as one write per interval, so the underlying logger sees
+// batches rather than single records.
+// The read and clear methods go straight to the underlying logger and do
+// not see the records still waiting in the queue
+type AsyncLogger struct {
+	logger Logger
+	queue  chan []byte
+	done   chan struct{}
+
+	policy        FullPolicy
+	flushInterval time.Duration
+	dropped       atomic.Int64
+
+	// protects closed, held for reading while sending to the queue so the
+	// queue is never closed under a sender
+	mu     sync.RWMutex
+	closed bool
+
+	errLock sync.Mutex
+	err     error
+}
+
+// FullPolicy says what Write does when the queue of an AsyncLogger is full
+type FullPolicy int
+
+const (
+	// BlockWhenFull makes Write wait for room in the queue
+	BlockWhenFull FullPolicy = iota
+	// DropWhenFull makes Write discard the record and count it in Dropped
+	DropWhenFull
+)
+
+// the most bytes gathered before a batch is forwarded early
+const asyncBatchSize = 64 * 1024
+
+// AsyncOption configures an AsyncLogger
+type AsyncOption func(*AsyncLogger)
+
+// WithFullPolicy sets what Write does when the queue is full
+func WithFullPolicy(p FullPolicy) AsyncOption {
+	return func(l *AsyncLogger) {
+		l.policy = p
+	}
+}
+
+// WithAsyncFlushInterval gathers the queued records and forwards them to the
+// underlying logger once per d, or earlier when 64KB have piled up. Zero,
+// the default, forwards every record as soon as it is dequeued
+func WithAsyncFlushInterval(d time.Duration) AsyncOption {
+	return func(l *AsyncLogger) {
+		l.flushInterval = d
+	}
+}
+
+// NewAsyncLogger creates an AsyncLogger over logger with a queue of
+// queueSize records
+func NewAsyncLogger(logger Logger, queueSize int, opts ...AsyncOption) *AsyncLogger {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	l := &AsyncLogger{logger: logger,
+		queue: make(chan []byte, queueSize),
+		done:  make(chan struct{})}
+	for _, opt := range opts {
+		opt(l)
+	}
+	go l.run()
+	return l
+}
+
+// forward the queued records to the underlying logger until the queue is closed
+func (l *AsyncLogger) run() {
+	defer close(l.done)
+	if l.flushInterval <= 0 {
+		for p := range l.queue {
+			l.forward(p)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+	var pending []byte
+	for {
+		select {
+		case p, ok := <-l.queue:
+			if !ok {
+				l.forward(pending)
+				return
+			}
+			pending = append(pending, p...)
+			if len(pending) >= asyncBatchSize {
+				l.forward(pending)
+				pending = pending[:0]
+			}
+		case <-ticker.C:
+			l.forward(pending)
+			pending = pending[:0]
+		}
+	}
+}
+
+// write p to the underlying logger
+func (l *AsyncLogger) forward(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	if _, err := l.logger.Write(p); err != nil {
+		l.setErr(err)
+	}
+}
+
+// remember the first error returned by the underlying logger
+func (l *AsyncLogger) setErr(err error) {
+	l.errLock.Lock()
+	defer l.errLock.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+}
+
+// Write queues a copy of p. When the queue is full it blocks or drops the
+// record, depending on the FullPolicy; a dropped record still reports len(p)
+func (l *AsyncLogger) Write(p []byte) (int, error) {
+	if l.policy == DropWhenFull {
+		ok, err := l.WriteNonBlocking(p)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			l.dropped.Add(1)
+		}
+		return len(p), nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	l.queue <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func (l *AsyncLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *AsyncLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+// WriteNonBlocking queues a copy of p and returns true, or returns false
+// immediately without queueing anything if the queue is full
+func (l *AsyncLogger) WriteNonBlocking(p []byte) (bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return false, ErrLoggerClosed
+	}
+	select {
+	case l.queue <- append([]byte(nil), p...):
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// Dropped returns how many records Write discarded because the queue was full
+func (l *AsyncLogger) Dropped() int64 {
+	return l.dropped.Load()
+}
+
+// Pressure returns how full the queue is, from 0 (empty) to 1 (full)
+func (l *AsyncLogger) Pressure() float64 {
+	return float64(len(l.queue)) / float64(cap(l.queue))
+}
+
+// Close writes all the queued and gathered records, closes the underlying logger and
+// returns the first error met while writing or closing
+func (l *AsyncLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrLoggerClosed
+	}
+	l.closed = true
+	close(l.queue)
+	l.mu.Unlock()
+
+	<-l.done
+	if err := l.logger.Close(); err != nil {
+		l.setErr(err)
+	}
+	l.errLock.Lock()
+	defer l.errLock.Unlock()
+	return l.err
+}
+
+func (l *AsyncLogger) ReadLog(offset int64, length int64) (string, error) {
+	return l.logger.ReadLog(offset, length)
+}
+
+func (l *AsyncLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return l.logger.ReadTailLog(offset, length)
+}
+
+func (l *AsyncLogger) ReadTailLines(n int) ([]string, error) {
+	return l.logger.ReadTailLines(n)
+}
+
+func (l *AsyncLogger) LineCount() (int64, error) {
+	return l.logger.LineCount()
+}
+
+func (l *AsyncLogger) Stats() LoggerStats {
+	return l.logger.Stats()
+}
+
+// Sync syncs the underlying logger, the records still in the queue are not
+// part of it
+func (l *AsyncLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func (l *AsyncLogger) ClearCurLogFile() error {
+	return l.logger.ClearCurLogFile()
+}
+
+func (l *AsyncLogger) ClearAllLogFile() error {
+	return l.logger.ClearAllLogFile()
+}
+
+func (l *AsyncLogger) ClearOldestBackup() (string, error) {
+	return l.logger.ClearOldestBackup()
+}
@@ -0,0 +1,241 @@
+package core_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// gateLogger keeps the writes in a MemoryLogger, each one waiting for gate
+// to be closed when it is set
+type gateLogger struct {
+	*core.MemoryLogger
+	gate chan struct{}
+	// receives a value as each write starts, if there is room
+	entered chan struct{}
+
+	lock   sync.Mutex
+	writes []string
+}
+
+func newGateLogger(blocked bool) *gateLogger {
+	l := &gateLogger{MemoryLogger: core.NewMemoryLogger(), entered: make(chan struct{}, 1)}
+	if blocked {
+		l.gate = make(chan struct{})
+	}
+	return l
+}
+
+func (l *gateLogger) Write(p []byte) (int, error) {
+	select {
+	case l.entered <- struct{}{}:
+	default:
+	}
+	if l.gate != nil {
+		<-l.gate
+	}
+	l.lock.Lock()
+	l.writes = append(l.writes, string(p))
+	l.lock.Unlock()
+	return l.MemoryLogger.Write(p)
+}
+
+// the writes received so far
+func (l *gateLogger) received() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string(nil), l.writes...)
+}
+
+func TestAsyncLoggerCloseDrains(t *testing.T) {
+	for _, interval := range []time.Duration{0, time.Hour} {
+		under := newGateLogger(true)
+		l := core.NewAsyncLogger(under, 100, core.WithAsyncFlushInterval(interval))
+		var want strings.Builder
+		for i := 0; i < 50; i++ {
+			line := fmt.Sprintf("record %d\n", i)
+			want.WriteString(line)
+			if _, err := l.Write([]byte(line)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		closed := make(chan error)
+		go func() { closed <- l.Close() }()
+		select {
+		case err := <-closed:
+			t.Fatalf("interval %v: Close returned %v with the records still queued", interval, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(under.gate)
+		if err := <-closed; err != nil {
+			t.Fatal(err)
+		}
+		if got := under.String(); got != want.String() {
+			t.Errorf("interval %v: forwarded %q, want %q", interval, got, want.String())
+		}
+		if _, err := l.Write([]byte("late\n")); err != core.ErrLoggerClosed {
+			t.Errorf("interval %v: Write after Close = %v, want ErrLoggerClosed", interval, err)
+		}
+		if err := l.Close(); err != core.ErrLoggerClosed {
+			t.Errorf("interval %v: second Close = %v, want ErrLoggerClosed", interval, err)
+		}
+	}
+}
+
+func TestAsyncLoggerOrder(t *testing.T) {
+	for _, interval := range []time.Duration{0, time.Millisecond} {
+		under := newGateLogger(false)
+		//a small queue keeps the writers blocked on it
+		l := core.NewAsyncLogger(under, 4, core.WithAsyncFlushInterval(interval))
+		const writers, records = 4, 500
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < records; i++ {
+					if _, err := l.Write([]byte(fmt.Sprintf("%d %d\n", w, i))); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		//the records of every writer come in the order written
+		next := make([]int, writers)
+		lines := strings.Split(strings.TrimSuffix(under.String(), "\n"), "\n")
+		for _, line := range lines {
+			var w, i int
+			if _, err := fmt.Sscanf(line, "%d %d", &w, &i); err != nil {
+				t.Fatalf("interval %v: bad line %q", interval, line)
+			}
+			if i != next[w] {
+				t.Fatalf("interval %v: writer %d record %d after %d", interval, w, i, next[w]-1)
+			}
+			next[w]++
+		}
+		if len(lines) != writers*records {
+			t.Errorf("interval %v: %d records forwarded, want %d", interval, len(lines), writers*records)
+		}
+	}
+}
+
+func TestAsyncLoggerFlushInterval(t *testing.T) {
+	under := newGateLogger(false)
+	l := core.NewAsyncLogger(under, 100, core.WithAsyncFlushInterval(200*time.Millisecond))
+	defer l.Close()
+	for i := 0; i < 10; i++ {
+		if _, err := l.Write([]byte(fmt.Sprintf("record %d\n", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := under.received(); len(got) != 0 {
+		t.Fatalf("forwarded %q before the interval", got)
+	}
+	eventually(t, "the batch", func() bool { return strings.Count(under.String(), "\n") == 10 })
+	if got := under.received(); len(got) != 1 {
+		t.Errorf("the records were forwarded in %d writes, want one batch", len(got))
+	}
+
+	//a batch of 64KB does not wait for the interval
+	under = newGateLogger(false)
+	l = core.NewAsyncLogger(under, 100, core.WithAsyncFlushInterval(time.Hour))
+	defer l.Close()
+	record := strings.Repeat("x", 1023) + "\n"
+	for i := 0; i < 64; i++ {
+		if _, err := l.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the early batch", func() bool { return len(under.received()) == 1 })
+	if got := under.received()[0]; len(got) != 64<<10 {
+		t.Errorf("early batch of %d bytes, want %d", len(got), 64<<10)
+	}
+}
+
+// an AsyncLogger over a blocked logger, its goroutine holding one record
+// and its queue of size records empty
+func newBlockedAsyncLogger(t *testing.T, size int, opts ...core.AsyncOption) (*core.AsyncLogger, *gateLogger) {
+	t.Helper()
+	under := newGateLogger(true)
+	l := core.NewAsyncLogger(under, size, opts...)
+	if _, err := l.Write([]byte("in flight\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-under.entered
+	return l, under
+}
+
+func TestAsyncLoggerDropWhenFull(t *testing.T) {
+	l, under := newBlockedAsyncLogger(t, 4, core.WithFullPolicy(core.DropWhenFull))
+	for i := 0; i < 10; i++ {
+		line := fmt.Sprintf("record %d\n", i)
+		if n, err := l.Write([]byte(line)); n != len(line) || err != nil {
+			t.Fatalf("Write = %d, %v, want %d, nil", n, err, len(line))
+		}
+	}
+	if n := l.Dropped(); n != 6 {
+		t.Errorf("Dropped = %d, want 6", n)
+	}
+	close(under.gate)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "in flight\nrecord 0\nrecord 1\nrecord 2\nrecord 3\n"; under.String() != want {
+		t.Errorf("forwarded %q, want %q", under.String(), want)
+	}
+}
+
+func TestAsyncLoggerWriteNonBlocking(t *testing.T) {
+	l, under := newBlockedAsyncLogger(t, 2)
+	for i, want := range []bool{true, true, false, false} {
+		if ok, err := l.WriteNonBlocking([]byte(fmt.Sprintf("record %d\n", i))); ok != want || err != nil {
+			t.Errorf("WriteNonBlocking %d = %t, %v, want %t, nil", i, ok, err, want)
+		}
+	}
+	//the records refused are the caller's to drop
+	if n := l.Dropped(); n != 0 {
+		t.Errorf("Dropped = %d, want 0", n)
+	}
+	close(under.gate)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "in flight\nrecord 0\nrecord 1\n"; under.String() != want {
+		t.Errorf("forwarded %q, want %q", under.String(), want)
+	}
+	if ok, err := l.WriteNonBlocking([]byte("late\n")); ok || err != core.ErrLoggerClosed {
+		t.Errorf("WriteNonBlocking after Close = %t, %v, want false, ErrLoggerClosed", ok, err)
+	}
+}
+
+func TestAsyncLoggerPressure(t *testing.T) {
+	l, under := newBlockedAsyncLogger(t, 4)
+	if p := l.Pressure(); p != 0 {
+		t.Errorf("Pressure of an empty queue = %v, want 0", p)
+	}
+	for i, want := range []float64{0.25, 0.5, 0.75, 1} {
+		if _, err := l.Write([]byte("record\n")); err != nil {
+			t.Fatal(err)
+		}
+		if p := l.Pressure(); p != want {
+			t.Errorf("Pressure with %d records queued = %v, want %v", i+1, p, want)
+		}
+	}
+	close(under.gate)
+	eventually(t, "the queue to empty", func() bool { return l.Pressure() == 0 })
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
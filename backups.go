@@ -0,0 +1,235 @@
+package core
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// logFile is a log file of the rotation found on disk
+type logFile struct {
+	name string
+	info os.FileInfo
+	// the ring index, or the time in the name for RotateTimestamp
+	index int
+	stamp time.Time
+}
+
+// return the number of files in the rotation ring
+func (l *FileLogger) ringSize() int {
+	if l.backups < 1 {
+		return 1
+	}
+	return l.backups
+}
+
+// return the log files existing on disk in chronological order, the current
+// one last. Files left by a previous run are included, the caller must hold
+// the lock
+func (l *FileLogger) listLogFiles() ([]logFile, error) {
+	entries, err := l.fs.ReadDir(filepath.Dir(l.name))
+	if err != nil {
+		return nil, err
+	}
+	if l.strategy == RotateTimestamp {
+		return l.listTimestampLogFiles(entries), nil
+	}
+	if l.strategy == RotateShift {
+		return l.listShiftLogFiles(entries), nil
+	}
+	found := make(map[int]os.FileInfo)
+	for _, fileInfo := range entries {
+		if n, ok := l.rotateIndex(fileInfo); ok {
+			//while being compressed a file has both versions, the plain
+			//one is complete
+			if prev, dup := found[n]; dup && !isCompressed(prev.Name()) {
+				continue
+			}
+			found[n] = fileInfo
+		}
+	}
+	//the oldest file is the next one to be reused by the ring
+	ring := l.ringSize()
+	files := make([]logFile, 0, len(found))
+	for i := 1; i <= ring; i++ {
+		n := (l.curRotate + i) % ring
+		if fileInfo, ok := found[n]; ok {
+			name := l.getLogFileName(n)
+			name += compressedExt(fileInfo.Name())
+			files = append(files, logFile{name: name, info: fileInfo, index: n})
+		}
+	}
+	return files, nil
+}
+
+// LogFileInfo describes a log file of a FileLogger, see GetLogFiles
+type LogFileInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	// Active is true for the current log file
+	Active bool
+}
+
+// GetLogFiles returns the log files on disk, the current one and the
+// backups, in chronological order with the current file last. It is empty
+// when no file exists yet
+func (l *FileLogger) GetLogFiles() ([]LogFileInfo, error) {
+	l.rlock()
+	defer l.runlock()
+
+	files, err := l.listLogFiles()
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	infos := make([]LogFileInfo, 0, len(files))
+	for _, f := range files {
+		infos = append(infos, LogFileInfo{Name: f.name,
+			Size:    f.info.Size(),
+			ModTime: f.info.ModTime(),
+			Active:  f.name == l.currentLogFile()})
+	}
+	return infos, nil
+}
+
+// ListBackups returns the names of the rotated log files on disk, oldest
+// first, without the current log file
+func (l *FileLogger) ListBackups() ([]string, error) {
+	l.rlock()
+	defer l.runlock()
+
+	return l.listBackups()
+}
+
+// the lock free part of ListBackups
+func (l *FileLogger) listBackups() ([]string, error) {
+	files, err := l.listLogFiles()
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	backups := make([]string, 0, len(files))
+	for _, f := range files {
+		if f.name != l.currentLogFile() {
+			backups = append(backups, f.name)
+		}
+	}
+	return backups, nil
+}
+
+// ErrNoBackup is returned by ClearOldestBackup when there is no backup
+var ErrNoBackup = errors.New("no backup to clear")
+
+// ClearOldestBackup removes the backup with the oldest modification time,
+// with its compressed version, and returns its name. The current log file
+// is never removed, ErrNoBackup is returned when it is the only file
+func (l *FileLogger) ClearOldestBackup() (string, error) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	files, err := l.listLogFiles()
+	if err != nil {
+		return "", NewFaultWrap(FAILED, "FAILED", err)
+	}
+	var oldest *logFile
+	for i, f := range files {
+		if f.name == l.currentLogFile() {
+			continue
+		}
+		if oldest == nil || f.info.ModTime().Before(oldest.info.ModTime()) {
+			oldest = &files[i]
+		}
+	}
+	if oldest == nil {
+		return "", ErrNoBackup
+	}
+	l.discardCompressed(trimCompressed(oldest.name))
+	if err = l.fs.Remove(oldest.name); err != nil && !os.IsNotExist(err) {
+		return "", NewFaultWrap(FAILED, "FAILED", err)
+	}
+	return oldest.name, nil
+}
+
+// ReadOlderLog reads a rotated log file like ReadLog reads the current one,
+// n is 1 for the most recent backup, 2 for the one before and so on
+func (l *FileLogger) ReadOlderLog(n int, offset int64, length int64) (string, error) {
+	if n < 1 {
+		return "", NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+
+	l.rlock()
+	defer l.runlock()
+
+	backups, err := l.listBackups()
+	if err != nil {
+		return "", err
+	}
+	if n > len(backups) {
+		return "", NewFault(NO_FILE, "NO_FILE")
+	}
+	return readFileString(l.fs, backups[len(backups)-n], l.aead, offset, length)
+}
+
+// decompressReadCloser closes both the decompressing reader and its file
+type decompressReadCloser struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (d *decompressReadCloser) Close() error {
+	d.ReadCloser.Close()
+	return d.file.Close()
+}
+
+// open a log file for reading, falling back to its compressed version
+// which is decompressed on the fly. An encrypted file is decrypted with
+// aead in memory
+func openLogFile(fs FS, fileName string, aead cipher.AEAD) (io.ReadCloser, error) {
+	f, err := fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if os.IsNotExist(err) && !isCompressed(fileName) {
+		fileName = findCompressed(fs, fileName)
+		f, err = fs.OpenFile(fileName, os.O_RDONLY, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, ok := compressorFor(fileName)
+	if !ok {
+		return decryptReader(f, aead)
+	}
+	zr, err := c.Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return decryptReader(&decompressReadCloser{ReadCloser: zr, file: f}, aead)
+}
+
+// EachBackupNewestFirst calls f with a reader over each rotated log file,
+// from the most recent one (n is 1) to the oldest one, until f returns stop
+// or an error. Compressed backups are decompressed, and a backup removed
+// since the listing is skipped. The reader is closed when f returns. The
+// logger is not locked while f runs, so f can use it
+func (l *FileLogger) EachBackupNewestFirst(f func(n int, r io.ReadCloser) (stop bool, err error)) error {
+	backups, err := l.ListBackups()
+	if err != nil {
+		return err
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		r, err := openLogFile(l.fs, backups[i], l.aead)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		stop, err := f(len(backups)-i, r)
+		r.Close()
+		if err != nil || stop {
+			return err
+		}
+	}
+	return nil
+}
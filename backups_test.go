@@ -0,0 +1,157 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// write the files of a previous run, the first one the oldest
+func populate(t *testing.T, files ...[2]string) {
+	t.Helper()
+	now := time.Now()
+	for i, f := range files {
+		if err := os.WriteFile(f[0], []byte(f[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(time.Duration(i-len(files)) * time.Hour)
+		if err := os.Chtimes(f[0], modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRestartReadsPreviousRun(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	populate(t, [2]string{name + ".2", "two\n"},
+		[2]string{name + ".0", "zero\n"},
+		[2]string{name + ".1", "one\n"})
+
+	l := newLogger(t, name, core.WithMaxSize(100), core.WithBackups(3))
+	if got := l.GetCurrentLogFile(); got != name+".1" {
+		t.Fatalf("current file %s, want the newest one %s.1", got, name)
+	}
+	write(t, l, "more\n")
+	if got, _ := l.ReadLog(0, 0); got != "one\nmore\n" {
+		t.Errorf("ReadLog = %q, want the newest file appended to", got)
+	}
+
+	backups, err := l.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{name + ".2", name + ".0"}; !reflect.DeepEqual(backups, want) {
+		t.Errorf("ListBackups = %v, want %v", backups, want)
+	}
+	for n, want := range map[int]string{1: "zero\n", 2: "two\n"} {
+		if got, err := l.ReadOlderLog(n, 0, 0); err != nil || got != want {
+			t.Errorf("ReadOlderLog(%d) = %q, %v, want %q", n, got, err, want)
+		}
+	}
+	if _, err := l.ReadOlderLog(3, 0, 0); err == nil {
+		t.Error("ReadOlderLog(3) read past the backups")
+	}
+
+	files, err := l.GetLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[2].Name != name+".1" || !files[2].Active || files[0].Active {
+		t.Errorf("GetLogFiles = %+v, want the current file last and active", files)
+	}
+}
+
+func TestDecoyFiles(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.v2.log")
+	populate(t, [2]string{name + ".0", "real\n"})
+	decoys := []string{"app.v2.log", "app.v2.log.old", "app.v2.log.+1", "app.v2.log.01", "app.v2.log.3",
+		"app.v2.log.1.bak", "app.v2.log.0.1", "app.log.1", "app.v2.log.gz", "v2.log.1"}
+	for _, decoy := range decoys {
+		if err := os.WriteFile(filepath.Join(dir, decoy), []byte("decoy\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//a directory with the name of a ring file is not a log file either
+	if err := os.MkdirAll(filepath.Join(dir, "app.v2.log.2", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newLogger(t, name, core.WithMaxSize(100), core.WithBackups(3))
+	if got := l.GetCurrentLogFile(); got != name+".0" {
+		t.Fatalf("current file %s, want the only real log file", got)
+	}
+	write(t, l, "more\n")
+	if got, _ := l.ReadLog(0, 0); got != "real\nmore\n" {
+		t.Errorf("ReadLog = %q", got)
+	}
+	if files := logFileNames(t, l); !reflect.DeepEqual(files, []string{name + ".0"}) {
+		t.Errorf("GetLogFiles = %v, want only %s.0", files, name)
+	}
+	if backups, _ := l.ListBackups(); len(backups) != 0 {
+		t.Errorf("ListBackups = %v, want none", backups)
+	}
+	if _, err := l.ClearOldestBackup(); err != core.ErrNoBackup {
+		t.Errorf("ClearOldestBackup = %v, want ErrNoBackup", err)
+	}
+	for _, decoy := range decoys {
+		if got := readFile(t, filepath.Join(dir, decoy)); got != "decoy\n" {
+			t.Errorf("decoy %s changed to %q", decoy, got)
+		}
+	}
+}
+
+func TestRestartWithEqualModTimes(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	stamp := time.Now()
+	for _, f := range [][2]string{{".0", "aaaa\n"}, {".1", "bb\n"}} {
+		if err := os.WriteFile(name+f[0], []byte(f[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(name+f[0], stamp, stamp)
+	}
+
+	//the full file was rotated out, the other one is continued
+	l := newLogger(t, name, core.WithMaxSize(5), core.WithBackups(3))
+	if got := l.GetCurrentLogFile(); got != name+".1" {
+		t.Fatalf("current file %s, want %s.1", got, name)
+	}
+	if got, _ := l.ReadLog(0, 0); got != "bb\n" {
+		t.Errorf("ReadLog = %q, want the file continued", got)
+	}
+}
+
+func TestBackslashInPath(t *testing.T) {
+	//a separator on Windows, a plain character of the name elsewhere
+	name := filepath.Join(t.TempDir(), `logs\app.log`)
+	dir := filepath.Dir(name)
+	l := newLogger(t, name, core.WithMaxSize(5), core.WithBackups(3))
+	write(t, l, "aaaa\n", "bb\n")
+	current := l.GetCurrentLogFile()
+	if filepath.Dir(current) != dir {
+		t.Fatalf("current file %s is not in %s", current, dir)
+	}
+	l.Close()
+
+	l = newLogger(t, name, core.WithMaxSize(5), core.WithBackups(3))
+	if got := l.GetCurrentLogFile(); got != current {
+		t.Errorf("current file after a restart %s, want %s", got, current)
+	}
+	if got, _ := l.ReadLog(0, 0); got != "bb\n" {
+		t.Errorf("ReadLog = %q, want the file continued", got)
+	}
+	if got, _ := l.ReadOlderLog(1, 0, 0); got != "aaaa\n" {
+		t.Errorf("ReadOlderLog = %q, want the backup found again", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d files in %s, want 2", len(entries), dir)
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// the ReadLog read sizes benchmarked, from a page to the whole test file
+var benchReadSizes = []int{4 << 10, 64 << 10, 1 << 20}
+
+// a logger whose current file holds size bytes of numbered lines
+func newBenchLogger(b *testing.B, size int, opts ...Option) *FileLogger {
+	b.Helper()
+	l := newTestLogger(b, 0, 0, opts...)
+	writeTestLines(b, l, size/64, 64)
+	return l
+}
+
+func BenchmarkReadLog(b *testing.B) {
+	l := newBenchLogger(b, 1<<20)
+	f, fileLen, err := l.openCurrentRange()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	//both read the same open file, leaving out the open of every ReadLog
+	for _, n := range benchReadSizes {
+		b.Run(fmt.Sprintf("pooled/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(n))
+			for i := 0; i < b.N; i++ {
+				if _, err := readAtRangeString(f, fileLen, 0, int64(n)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		//reading into a new buffer, then copying it to the string
+		b.Run(fmt.Sprintf("unpooled/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(n))
+			for i := 0; i < b.N; i++ {
+				data, err := ReadAtRange(f, fileLen, 0, int64(n))
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = string(data)
+			}
+		})
+	}
+}
+
+func BenchmarkReadFrom(b *testing.B) {
+	data := bytes.Repeat([]byte("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.\n"), 1<<14)
+	l := newTestLogger(b, 64<<20, 2)
+
+	b.Run("ReadFrom", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := l.ReadFrom(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	//io.Copy with neither io.ReaderFrom nor io.WriterTo to use, writing
+	//the chunks of its own buffer
+	b.Run("Write", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			if _, err := io.Copy(struct{ io.Writer }{l}, struct{ io.Reader }{bytes.NewReader(data)}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkReadLogMmap(b *testing.B) {
+	if testing.Short() {
+		b.Skip("writes a 100MB file")
+	}
+	const size = 100 << 20
+	chunk := bytes.Repeat([]byte("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.\n"), 1<<14)
+	for _, mmap := range []bool{false, true} {
+		l := newTestLogger(b, 0, 0, WithMmap(mmap))
+		for n := 0; n < size; n += len(chunk) {
+			if _, err := l.Write(chunk); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run(fmt.Sprintf("mmap=%t/page", mmap), func(b *testing.B) {
+			//the mapping is made by the first read
+			if _, err := l.ReadLog(0, 1); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.SetBytes(4 << 10)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				//pages spread over the whole file
+				offset := int64(i) * 7919 * 4096 % size
+				if _, err := l.ReadLog(offset, 4<<10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("mmap=%t/tail", mmap), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := l.ReadLinesReverse(0, 100); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// BinaryLogger writes length-prefixed records to an underlying Logger,
+// usually a rotating FileLogger. Every record is written with a single
+// Write call so it is never split across a rotation.
+//
+// The frame format of a record is:
+//
+//	+---------+-----------------+--------------------+-----------+
+//	| flags   | length          | timestamp          | payload   |
+//	| 1 byte  | 4 bytes, BE     | 8 bytes, BE, opt.  | length B  |
+//	+---------+-----------------+--------------------+-----------+
+//
+// Bit 0 of flags tells if the timestamp is present, the other bits are
+// reserved and always 0. The timestamp is the number of nanoseconds since
+// the Unix epoch as a signed integer. The length is the payload length only,
+// at most MaxBinaryRecordSize.
+//
+// The underlying logger must write the bytes unchanged, so options that
+// rewrite the content (like WithMaxLineBytes) must not be used with it
+type BinaryLogger struct {
+	logger    Logger
+	timestamp bool
+}
+
+// BinaryRecord is a record read back by ReadBinary
+type BinaryRecord struct {
+	// Time is the zero time if the record has no timestamp
+	Time time.Time
+	Data []byte
+}
+
+// MaxBinaryRecordSize is the largest payload of a binary record. ReadBinary
+// checks it before allocating the payload, so a corrupt length can't make
+// it allocate gigabytes
+const MaxBinaryRecordSize = 64 << 20
+
+// ErrBinaryRecordTooLarge is returned by ReadBinary for a record longer than
+// MaxBinaryRecordSize
+var ErrBinaryRecordTooLarge = errors.New("binary record too large")
+
+const (
+	binaryFlagTimestamp = 1 << 0
+	binaryHeaderLen     = 1 + 4
+	binaryTimestampLen  = 8
+)
+
+func NewBinaryLogger(logger Logger, timestamp bool) *BinaryLogger {
+	return &BinaryLogger{logger: logger, timestamp: timestamp}
+}
+
+// WriteRecord frames p as one record and writes it to the underlying logger.
+// A record longer than MaxBinaryRecordSize is BAD_ARGUMENTS
+func (b *BinaryLogger) WriteRecord(p []byte) error {
+	if len(p) > MaxBinaryRecordSize {
+		return NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	size := binaryHeaderLen + len(p)
+	if b.timestamp {
+		size += binaryTimestampLen
+	}
+	frame := make([]byte, binaryHeaderLen, size)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(p)))
+	if b.timestamp {
+		frame[0] |= binaryFlagTimestamp
+		frame = binary.BigEndian.AppendUint64(frame, uint64(time.Now().UnixNano()))
+	}
+	frame = append(frame, p...)
+	_, err := b.logger.Write(frame)
+	return err
+}
+
+// Write writes p as one record, so it implements io.Writer
+func (b *BinaryLogger) Write(p []byte) (int, error) {
+	if err := b.WriteRecord(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (b *BinaryLogger) Close() error {
+	return b.logger.Close()
+}
+
+// ReadBinary reads the records written by a BinaryLogger from r and calls f
+// for each of them in order. It stops at the first error returned by f, and
+// returns io.ErrUnexpectedEOF if r ends in the middle of a record and
+// ErrBinaryRecordTooLarge for a length over MaxBinaryRecordSize
+func ReadBinary(r io.Reader, f func(rec BinaryRecord) error) error {
+	header := make([]byte, binaryHeaderLen+binaryTimestampLen)
+	for {
+		_, err := io.ReadFull(r, header[:binaryHeaderLen])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		flags := header[0]
+		length := binary.BigEndian.Uint32(header[1:binaryHeaderLen])
+		if length > MaxBinaryRecordSize {
+			return ErrBinaryRecordTooLarge
+		}
+		rec := BinaryRecord{}
+		if flags&binaryFlagTimestamp != 0 {
+			if _, err = io.ReadFull(r, header[binaryHeaderLen:]); err != nil {
+				return unexpectedEOF(err)
+			}
+			rec.Time = time.Unix(0, int64(binary.BigEndian.Uint64(header[binaryHeaderLen:])))
+		}
+		rec.Data = make([]byte, length)
+		if _, err = io.ReadFull(r, rec.Data); err != nil {
+			return unexpectedEOF(err)
+		}
+		if err = f(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// a record cut in the middle is always an unexpected EOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
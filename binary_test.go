@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	records := [][]byte{[]byte("first"), {}, {0, '\n', 0xff, 0}, bytes.Repeat([]byte("x"), 100<<10)}
+	for _, timestamp := range []bool{false, true} {
+		name := filepath.Join(t.TempDir(), "test.bin")
+		l := newLogger(t, name, core.WithMaxSize(0), core.WithBackups(0))
+		bl := core.NewBinaryLogger(l, timestamp)
+		before := time.Now()
+		for _, rec := range records {
+			if err := bl.WriteRecord(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		after := time.Now()
+		if err := bl.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(l.GetCurrentLogFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got [][]byte
+		err = core.ReadBinary(f, func(rec core.BinaryRecord) error {
+			if timestamp && (rec.Time.Before(before.Truncate(0)) || rec.Time.After(after)) {
+				t.Errorf("timestamp %v not within the writes", rec.Time)
+			}
+			if !timestamp && !rec.Time.IsZero() {
+				t.Errorf("timestamp %v in a record written without", rec.Time)
+			}
+			got = append(got, rec.Data)
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(records) {
+			t.Fatalf("timestamp %t: read %d records, want %d", timestamp, len(got), len(records))
+		}
+		for i := range records {
+			if !bytes.Equal(got[i], records[i]) {
+				t.Errorf("timestamp %t: record %d = %.20q, want %.20q", timestamp, i, got[i], records[i])
+			}
+		}
+	}
+}
+
+// the records written by a BinaryLogger to memory
+func binaryFrames(t *testing.T, timestamp bool, records ...string) []byte {
+	t.Helper()
+	l := core.NewMemoryLogger()
+	bl := core.NewBinaryLogger(l, timestamp)
+	for _, rec := range records {
+		if err := bl.WriteRecord([]byte(rec)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return []byte(l.String())
+}
+
+func TestReadBinaryErrors(t *testing.T) {
+	plain := binaryFrames(t, false, "first", "second")
+	stamped := binaryFrames(t, true, "first", "second")
+	oversized := []byte{0, 0xff, 0xff, 0xff, 0xff}
+	tests := []struct {
+		name string
+		in   []byte
+		// the records read before the error
+		records []string
+		err     error
+	}{
+		{"empty", nil, nil, nil},
+		{"truncated header", plain[:len(plain)-len("second")-2], []string{"first"}, io.ErrUnexpectedEOF},
+		{"truncated timestamp", stamped[:len(stamped)-len("second")-3], []string{"first"}, io.ErrUnexpectedEOF},
+		{"truncated payload", plain[:len(plain)-1], []string{"first"}, io.ErrUnexpectedEOF},
+		{"oversized length", append(append([]byte(nil), plain...), oversized...), []string{"first", "second"}, core.ErrBinaryRecordTooLarge},
+	}
+	for _, tt := range tests {
+		var records []string
+		err := core.ReadBinary(bytes.NewReader(tt.in), func(rec core.BinaryRecord) error {
+			records = append(records, string(rec.Data))
+			return nil
+		})
+		if err != tt.err {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+		if !reflect.DeepEqual(records, tt.records) {
+			t.Errorf("%s: records %q, want %q", tt.name, records, tt.records)
+		}
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := core.ReadBinary(bytes.NewReader(plain), func(rec core.BinaryRecord) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("ReadBinary = %v after %d records, want the error of f after 1", err, n)
+	}
+}
+
+func TestWriteBinaryRecordTooLarge(t *testing.T) {
+	l := core.NewMemoryLogger()
+	bl := core.NewBinaryLogger(l, false)
+	err := bl.WriteRecord(make([]byte, core.MaxBinaryRecordSize+1))
+	var fault *core.Fault
+	if !errors.As(err, &fault) || fault.Code != core.BAD_ARGUMENTS {
+		t.Errorf("WriteRecord of %d bytes = %v, want a BAD_ARGUMENTS fault", core.MaxBinaryRecordSize+1, err)
+	}
+	if n := len(l.String()); n != 0 {
+		t.Errorf("%d bytes written for a rejected record", n)
+	}
+}
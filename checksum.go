@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// suffix of the checksum sidecar of a rotated file
+const checksumSuffix = ".sha256"
+
+// checksumJob is a rotated file being hashed in the background, it is
+// cancelled if the file is reused or removed before the end
+type checksumJob struct {
+	cancelled bool
+	// the file hashed, a RotateShift rotation renames it
+	name string
+}
+
+// WithChecksum writes a name.N.sha256 sidecar next to every rotated file,
+// holding the hex SHA-256 of its content, to detect tampering with
+// VerifyBackup. The hash is computed in the background, so it doesn't
+// delay the writes, and covers the content before any compression or
+// encryption
+func WithChecksum(checksum bool) Option {
+	return func(l *FileLogger) {
+		l.checksum = checksum
+	}
+}
+
+// start hashing a file just rotated out, the caller must hold the lock
+func (l *FileLogger) checksumLater(fileName string) {
+	f, err := l.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		l.handleError(err)
+		return
+	}
+	if l.checksumming == nil {
+		l.checksumming = make(map[string]*checksumJob)
+	}
+	job := &checksumJob{name: fileName}
+	l.checksumming[fileName] = job
+	l.compressWG.Add(1)
+	go l.checksumFile(job, f)
+}
+
+// hash a rotated file without holding the lock, then take it to write the
+// sidecar of the file
+func (l *FileLogger) checksumFile(job *checksumJob, f *os.File) {
+	defer l.compressWG.Done()
+
+	sum, err := l.hashFile(f)
+
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	if l.checksumming[job.name] == job {
+		delete(l.checksumming, job.name)
+	}
+	if err == nil && !job.cancelled {
+		err = l.writeChecksum(job.name+checksumSuffix, sum)
+	}
+	if err != nil {
+		l.handleError(err)
+	}
+}
+
+// write the sidecar of a file at once, the caller must hold the lock
+func (l *FileLogger) checksumNow(fileName string) error {
+	f, err := l.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	sum, err := l.hashFile(f)
+	if err != nil {
+		return err
+	}
+	return l.writeChecksum(fileName+checksumSuffix, sum)
+}
+
+// return the hex SHA-256 of the decrypted content of f, and close it
+func (l *FileLogger) hashFile(f *os.File) (string, error) {
+	r, err := decryptReader(f, l.aead)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return hashReader(r)
+}
+
+// return the hex SHA-256 of what r gives
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// write a checksum sidecar
+func (l *FileLogger) writeChecksum(fileName string, sum string) error {
+	out, err := l.fs.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, l.fileMode)
+	if err != nil {
+		return err
+	}
+	_, err = out.WriteString(sum + "\n")
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// forget the hash of fileName and remove its sidecar, because the file is
+// about to be reused or removed. The caller must hold the lock
+func (l *FileLogger) discardChecksum(fileName string) {
+	if job, ok := l.checksumming[fileName]; ok {
+		job.cancelled = true
+		delete(l.checksumming, fileName)
+	}
+	if err := l.fs.Remove(fileName + checksumSuffix); err != nil && !os.IsNotExist(err) {
+		l.handleError(err)
+	}
+}
+
+// VerifyBackup hashes a rotated log file again and tells if it still
+// matches its WithChecksum sidecar, n is 1 for the most recent backup like
+// for ReadOlderLog. A compressed backup is hashed decompressed. A backup
+// without sidecar, or whose hash is not written yet, returns a NO_FILE
+// Fault
+func (l *FileLogger) VerifyBackup(n int) (bool, error) {
+	if n < 1 {
+		return false, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l.rlock()
+	backups, err := l.listBackups()
+	if err != nil {
+		l.runlock()
+		return false, err
+	}
+	if n > len(backups) {
+		l.runlock()
+		return false, NewFault(NO_FILE, "NO_FILE")
+	}
+	fileName := backups[len(backups)-n]
+	stored, err := readFile(l.fs, trimCompressed(fileName)+checksumSuffix)
+	var r io.ReadCloser
+	if err == nil {
+		r, err = openLogFile(l.fs, fileName, l.aead)
+	}
+	l.runlock()
+	if err != nil {
+		return false, NewFaultWrap(NO_FILE, "NO_FILE", err)
+	}
+	defer r.Close()
+
+	sum, err := hashReader(r)
+	if err != nil {
+		return false, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	return string(bytes.TrimSpace(stored)) == sum, nil
+}
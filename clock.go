@@ -0,0 +1,51 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time to a FileLogger, so the time based behaviour can be
+// tested without sleeping
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the wall clock
+type realClock struct {
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// TickerClock is a Clock which also makes the tickers of the logger, so a
+// test can fire the periodic work itself. NewTicker returns the channel of
+// the ticks and the function stopping them
+type TickerClock interface {
+	Clock
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+// return a ticker of period d from the logger clock, or a time.Ticker
+func (l *FileLogger) newTicker(d time.Duration) (<-chan time.Time, func()) {
+	if c, ok := l.clock.(TickerClock); ok {
+		return c.NewTicker(d)
+	}
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// WithClock makes the logger read the time from clock instead of the wall
+// clock
+func WithClock(clock Clock) Option {
+	return func(l *FileLogger) {
+		l.clock = clock
+	}
+}
+
+// NewFileLoggerWithClock creates a FileLogger reading the time from clock,
+// for the rotation age and every other time based decision
+func NewFileLoggerWithClock(name string, maxSize int64, backups int, locker sync.Locker, clock Clock, opts ...Option) *FileLogger {
+	return NewFileLogger(name, maxSize, backups, locker, append([]Option{WithClock(clock)}, opts...)...)
+}
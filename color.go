@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"os"
+)
+
+// the ANSI colors of the level tokens
+var levelColors = map[Level]string{
+	LevelDebug: "\x1b[90m",
+	LevelInfo:  "\x1b[36m",
+	LevelWarn:  "\x1b[33m",
+	LevelError: "\x1b[31m",
+	LevelFatal: "\x1b[1;31m",
+}
+
+// resets the color after a level token
+const colorReset = "\x1b[0m"
+
+// ColorStdoutLogger is a StdoutLogger coloring the level token a line
+// starts with, like [ERROR] in red and [WARN] in yellow, see LevelLogger
+// for the tokens. The colors are only written if stdout is a terminal when
+// the logger is created, so a redirected output is left unchanged
+type ColorStdoutLogger struct {
+	StdoutLogger
+	color bool
+}
+
+func NewColorStdoutLogger() *ColorStdoutLogger {
+	color := false
+	if fileInfo, err := os.Stdout.Stat(); err == nil {
+		color = fileInfo.Mode()&os.ModeCharDevice != 0
+	}
+	return &ColorStdoutLogger{color: color}
+}
+
+// Write writes p to stdout with its level tokens colored, it reports
+// len(p) without the color codes
+func (l *ColorStdoutLogger) Write(p []byte) (int, error) {
+	if !l.color {
+		return os.Stdout.Write(p)
+	}
+	if _, err := os.Stdout.Write(colorLevels(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (l *ColorStdoutLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *ColorStdoutLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *ColorStdoutLogger) WriteString(s string) (int, error) {
+	return l.Write([]byte(s))
+}
+
+// return p with the level token starting each line wrapped in its color
+func colorLevels(p []byte) []byte {
+	out := make([]byte, 0, len(p)+32)
+	for len(p) > 0 {
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+		}
+		p = p[len(line):]
+		level, ok := parseLevel(line)
+		if !ok {
+			out = append(out, line...)
+			continue
+		}
+		start := len(line) - len(bytes.TrimLeft(line, " \t"))
+		end := bytes.IndexByte(line, ']') + 1
+		out = append(out, line[:start]...)
+		out = append(out, levelColors[level]...)
+		out = append(out, line[start:end]...)
+		out = append(out, colorReset...)
+		out = append(out, line[end:]...)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"io"
+	"os"
+)
+
+// multiReaderAt reads a sequence of files as one, each part starting at
+// the end of the previous one
+type multiReaderAt struct {
+	files  []rangeFile
+	starts []int64
+	size   int64
+}
+
+func (m *multiReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	total := 0
+	for i, f := range m.files {
+		end := m.size
+		if i+1 < len(m.starts) {
+			end = m.starts[i+1]
+		}
+		if off >= end || len(p) == 0 {
+			continue
+		}
+		want := end - off
+		if want > int64(len(p)) {
+			want = int64(len(p))
+		}
+		n, err := f.ReadAt(p[:want], off-m.starts[i])
+		total += n
+		if err != nil && !(err == io.EOF && int64(n) == want) {
+			return total, err
+		}
+		p = p[n:]
+		off += int64(n)
+	}
+	if len(p) > 0 {
+		return total, io.EOF
+	}
+	return total, nil
+}
+
+func (m *multiReaderAt) Close() error {
+	var err error
+	for _, f := range m.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ReadCombinedLog reads the backups, oldest first, and the current log file
+// as one stream, with the ReadLog rules for offset and length. A backup
+// removed since the listing is left out, and one compressed since then is
+// read from its compressed version
+func (l *FileLogger) ReadCombinedLog(offset int64, length int64) (string, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", err
+	}
+	l.rlock()
+	defer l.runlock()
+
+	files, err := l.listLogFiles()
+	if err != nil {
+		return "", NewFaultWrap(FAILED, "FAILED", err)
+	}
+	m := &multiReaderAt{}
+	defer m.Close()
+	for _, file := range files {
+		f, size, err := openRange(l.fs, file.name, l.aead)
+		if os.IsNotExist(err) && !isCompressed(file.name) {
+			f, size, err = openRange(l.fs, findCompressed(l.fs, file.name), l.aead)
+		}
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", NewFaultWrap(FAILED, "FAILED", err)
+		}
+		m.files = append(m.files, f)
+		m.starts = append(m.starts, m.size)
+		m.size += size
+	}
+
+	return readAtRangeString(m, m.size, offset, length)
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"io"
+	"os"
+)
+
+// CompactBackups merges adjacent rotated files, oldest first, into files of
+// about targetSize bytes to reduce the number of backups. Each merged file
+// takes the name of the newest file of its group, so the chronological
+// order of the backups is unchanged; the ring then has free slots.
+//
+// A group is first written to a temporary file that is renamed over its
+// newest file, the other files of the group being removed afterwards: a
+// crash can leave some content twice but never loses any. The current file
+// is never touched and the logger is locked during the whole compaction. A
+// file still being compressed or hashed is left out. With WithChecksum the
+// sidecar of a merged file is written again, and with WithCompress the
+// merged file is compressed in the background like a rotated one
+func (l *FileLogger) CompactBackups(targetSize int64) error {
+	if targetSize <= 0 {
+		return NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	files, err := l.listLogFiles()
+	if err != nil {
+		return NewFaultWrap(FAILED, "FAILED", err)
+	}
+	groups := make([][]logFile, 0)
+	var group []logFile
+	size := int64(0)
+	for _, f := range files {
+		//a compressed file can't be concatenated with plain ones, and a
+		//running job would replace or hash the file merged
+		if f.name == l.currentLogFile() || isCompressed(f.name) || l.busy(f.name) {
+			if len(group) > 0 {
+				groups = append(groups, group)
+				group = nil
+				size = 0
+			}
+			continue
+		}
+		if len(group) > 0 && size+f.info.Size() > targetSize {
+			groups = append(groups, group)
+			group = nil
+			size = 0
+		}
+		group = append(group, f)
+		size += f.info.Size()
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		if err := l.mergeFiles(group); err != nil {
+			return NewFaultWrap(FAILED, "FAILED", err)
+		}
+	}
+	return nil
+}
+
+// tell if a background job still works on a rotated file
+func (l *FileLogger) busy(fileName string) bool {
+	if _, ok := l.compressing[fileName]; ok {
+		return true
+	}
+	_, ok := l.checksumming[fileName]
+	return ok
+}
+
+// concatenate the files into a temporary file renamed over the last one,
+// then remove the others. The merged file keeps the modification time of
+// the last one, which RotateRing uses to find the current file on restart
+func (l *FileLogger) mergeFiles(group []logFile) error {
+	target := group[len(group)-1].name
+	modTime := group[len(group)-1].info.ModTime()
+	tmpName := target + ".compact.tmp"
+	tmp, err := l.fs.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, l.fileMode)
+	if err != nil {
+		return err
+	}
+	for _, f := range group {
+		if err = appendFile(l.fs, tmp, f.name); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = os.Chtimes(tmpName, modTime, modTime)
+	}
+	if err == nil {
+		err = l.fs.Rename(tmpName, target)
+	}
+	if err != nil {
+		l.fs.Remove(tmpName)
+		return err
+	}
+	//the sidecars no longer match the files
+	for _, f := range group {
+		l.discardChecksum(f.name)
+	}
+	for _, f := range group[:len(group)-1] {
+		if err = l.fs.Remove(f.name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if l.checksum {
+		if err := l.checksumNow(target); err != nil {
+			return err
+		}
+	}
+	if l.compress {
+		l.compressLater(target)
+	}
+	return nil
+}
+
+// copy the content of a file at the end of w
+func appendFile(fs FS, w io.Writer, fileName string) error {
+	f, err := fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
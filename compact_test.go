@@ -0,0 +1,132 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompactBackups(t *testing.T) {
+	l := newTestLogger(t, 100, 10)
+	writeTestLines(t, l, 40, 50)
+	before, err := l.ReadCombinedLog(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := l.ListBackups()
+	current := l.GetCurrentLogFile()
+
+	if err := l.CompactBackups(400); err != nil {
+		t.Fatal(err)
+	}
+	after, err := l.ReadCombinedLog(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Fatalf("content changed by the compaction")
+	}
+	compacted, _ := l.ListBackups()
+	if len(compacted) >= len(backups) {
+		t.Fatalf("%d backups after compaction, %d before", len(compacted), len(backups))
+	}
+	if l.GetCurrentLogFile() != current {
+		t.Fatalf("current file moved to %s", l.GetCurrentLogFile())
+	}
+	if _, err := os.Stat(compacted[0] + ".compact.tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left: %v", err)
+	}
+}
+
+func TestCompactBackupsBadArguments(t *testing.T) {
+	l := newTestLogger(t, 100, 10)
+	if err := l.CompactBackups(0); err == nil {
+		t.Fatal("no error for a zero target size")
+	}
+}
+
+func TestCompactBackupsKeepsCurrentOnRestart(t *testing.T) {
+	l := newTestLogger(t, 100, 10)
+	writeTestLines(t, l, 30, 50)
+	current := l.GetCurrentLogFile()
+	//the merged files must look older than the current one whatever the
+	//clock resolution
+	old := time.Now().Add(-time.Hour)
+	backups, _ := l.ListBackups()
+	for i, b := range backups {
+		stamp := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(b, stamp, stamp)
+	}
+	if err := l.CompactBackups(1000); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	restarted, err := NewFileLoggerE(l.name, 100, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if restarted.GetCurrentLogFile() != current {
+		t.Fatalf("restarted on %s, current file was %s", restarted.GetCurrentLogFile(), current)
+	}
+}
+
+func TestCompactBackupsSkipsBusyFiles(t *testing.T) {
+	l := newTestLogger(t, 100, 10)
+	writeTestLines(t, l, 20, 50)
+	backups, _ := l.ListBackups()
+	busy := backups[1]
+	l.locker.Lock()
+	l.compressing[busy] = &compressJob{name: busy}
+	l.locker.Unlock()
+
+	if err := l.CompactBackups(1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(busy); err != nil {
+		t.Fatalf("file being compressed was merged: %v", err)
+	}
+	l.locker.Lock()
+	delete(l.compressing, busy)
+	l.locker.Unlock()
+}
+
+func TestCompactBackupsChecksum(t *testing.T) {
+	l := newTestLogger(t, 100, 10, WithChecksum(true))
+	writeTestLines(t, l, 30, 50)
+	l.compressWG.Wait()
+	if err := l.CompactBackups(1000); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := l.ListBackups()
+	for n := 1; n <= len(backups); n++ {
+		ok, err := l.VerifyBackup(n)
+		if err != nil || !ok {
+			t.Fatalf("backup %d: %v %v", n, ok, err)
+		}
+	}
+	matches, _ := filepath.Glob(l.name + ".*" + checksumSuffix)
+	if len(matches) != len(backups) {
+		t.Fatalf("%d sidecars for %d backups", len(matches), len(backups))
+	}
+}
+
+func TestCompactBackupsCompress(t *testing.T) {
+	l := newTestLogger(t, 100, 10)
+	writeTestLines(t, l, 30, 50)
+	before, _ := l.ReadCombinedLog(0, 0)
+	l.compress = true
+	if err := l.CompactBackups(1000); err != nil {
+		t.Fatal(err)
+	}
+	l.compressWG.Wait()
+	backups, _ := l.ListBackups()
+	if !isCompressed(backups[0]) {
+		t.Fatalf("merged file %s not compressed", backups[0])
+	}
+	if after, _ := l.ReadCombinedLog(0, 0); after != before {
+		t.Fatal("content changed by the compaction")
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// compressJob is a rotated file being compressed in the background, it is
+// cancelled if the file is reused or removed before the end
+type compressJob struct {
+	cancelled bool
+	// the file being compressed, a RotateShift rotation renames it
+	name string
+	// the extension of the compressed file and the temporary compressed
+	// file, unique to the job
+	ext string
+	tmp string
+}
+
+// WithCompress compresses every rotated file to name.N.gz in the background
+func WithCompress(compress bool) Option {
+	return func(l *FileLogger) {
+		l.compress = compress
+	}
+}
+
+// NewCompressedFileLogger creates a FileLogger compressing its rotated files
+func NewCompressedFileLogger(name string, maxSize int64, backups int, locker sync.Locker, opts ...Option) *FileLogger {
+	return NewFileLogger(name, maxSize, backups, locker, append(opts, WithCompress(true))...)
+}
+
+// check if a log file name is the one of a compressed file
+func isCompressed(fileName string) bool {
+	_, ok := compressorFor(fileName)
+	return ok
+}
+
+// return the codec of the rotated files
+func (l *FileLogger) getCompressor() Compressor {
+	if l.compressor == nil {
+		return GzipCompressor{}
+	}
+	return l.compressor
+}
+
+// start compressing a file just rotated out, the caller must hold the lock
+func (l *FileLogger) compressLater(fileName string) {
+	l.compressSeq++
+	job := &compressJob{name: fileName,
+		ext: l.getCompressor().Extension(),
+		tmp: fmt.Sprintf("%s%s.%d.tmp", fileName, l.getCompressor().Extension(), l.compressSeq)}
+	l.compressing[fileName] = job
+	l.compressWG.Add(1)
+	go l.compressFile(job)
+}
+
+// compress a rotated file without holding the lock, then take it to replace
+// the file by its compressed version
+func (l *FileLogger) compressFile(job *compressJob) {
+	defer l.compressWG.Done()
+
+	//the name only changes under the lock
+	l.locker.Lock()
+	in, err := l.fs.OpenFile(job.name, os.O_RDONLY, 0)
+	l.locker.Unlock()
+	if err == nil {
+		err = l.compressTo(in, job.tmp)
+		in.Close()
+	}
+
+	l.locker.Lock()
+	defer l.locker.Unlock()
+	if l.compressing[job.name] == job {
+		delete(l.compressing, job.name)
+	}
+	if err == nil && !job.cancelled {
+		err = l.fs.Rename(job.tmp, job.name+job.ext)
+		if err == nil {
+			err = l.fs.Remove(job.name)
+		}
+	}
+	if err != nil || job.cancelled {
+		l.fs.Remove(job.tmp)
+	}
+	if err != nil {
+		l.handleError(err)
+	}
+}
+
+// write the compressed content of in to dst
+func (l *FileLogger) compressTo(in io.Reader, dst string) error {
+	out, err := l.fs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, l.fileMode)
+	if err != nil {
+		return err
+	}
+	err = l.getCompressor().Compress(out, in)
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// forget the compression of fileName and remove its stale compressed
+// versions and checksum, because the file is about to be reused or
+// removed. The caller must hold the lock
+func (l *FileLogger) discardCompressed(fileName string) {
+	if job, ok := l.compressing[fileName]; ok {
+		job.cancelled = true
+		delete(l.compressing, fileName)
+	}
+	l.discardChecksum(fileName)
+	for _, ext := range compressExtensions() {
+		if err := l.fs.Remove(fileName + ext); err != nil && !os.IsNotExist(err) {
+			l.handleError(err)
+		}
+	}
+}
+
+// rangeFile is a log file opened for random access reads
+type rangeFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// memFile is a decompressed log file held in memory
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error {
+	return nil
+}
+
+// open a log file for random access reads and return it with its size. A
+// compressed or encrypted file is decompressed and decrypted with aead in
+// memory, so reading it costs its whole size whatever the range read
+func openRange(fs FS, fileName string, aead cipher.AEAD) (rangeFile, int64, error) {
+	f, err := fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	c, compressed := compressorFor(fileName)
+	if !compressed {
+		statInfo, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return decryptRange(f, statInfo.Size(), aead)
+	}
+	defer f.Close()
+	zr, err := c.Decompress(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decryptRange(memFile{bytes.NewReader(b)}, int64(len(b)), aead)
+}
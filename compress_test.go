@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadTailOfCompressedFile(t *testing.T) {
+	l := newTestLogger(t, 100, 3, WithCompress(true))
+	content := strings.Repeat("0123456789", 9) + "012345678\n"
+	if _, err := l.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	l.compressWG.Wait()
+	backups, err := l.ListBackups()
+	if err != nil || len(backups) != 1 || !isCompressed(backups[0]) {
+		t.Fatalf("backups %v, %v, want one compressed file", backups, err)
+	}
+	if _, err := os.Stat(trimCompressed(backups[0])); !os.IsNotExist(err) {
+		t.Fatalf("plain file left next to %s", backups[0])
+	}
+
+	//the offsets count the decompressed bytes
+	f, fileLen, err := openRange(l.fs, backups[0], l.aead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fileLen != int64(len(content)) {
+		t.Fatalf("size %d, want the decompressed size %d", fileLen, len(content))
+	}
+	tests := []struct {
+		offset, length int64
+		want           string
+		next           int64
+		overflow       bool
+	}{
+		{0, 10, content[:10], 10, false},
+		{90, 10, content[90:], 100, false},
+		{95, 20, content[95:], 100, false},
+		{100, 10, "", 100, true},
+		{150, 10, content[:10], 10, true},
+	}
+	for _, tt := range tests {
+		got, next, overflow, err := ReadTailAt(f, fileLen, tt.offset, tt.length)
+		if err != nil || got != tt.want || next != tt.next || overflow != tt.overflow {
+			t.Errorf("ReadTailAt(%d, %d) = %q, %d, %v, %v, want %q, %d, %v",
+				tt.offset, tt.length, got, next, overflow, err, tt.want, tt.next, tt.overflow)
+		}
+	}
+	if got, err := l.ReadOlderLog(1, -10, 0); err != nil || got != content[90:] {
+		t.Errorf("ReadOlderLog = %q, %v, want the decompressed tail", got, err)
+	}
+}
+
+func TestReadTailLogCompressedCurrentFile(t *testing.T) {
+	l := newTestLogger(t, 100, 3, WithCompress(true), WithRotationStrategy(RotateTimestamp))
+	content := strings.Repeat("0123456789", 9) + "012345678\n"
+	if _, err := l.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	l.compressWG.Wait()
+	backups, err := l.ListBackups()
+	if err != nil || len(backups) != 1 || !isCompressed(backups[0]) {
+		t.Fatalf("backups %v, %v, want one compressed file", backups, err)
+	}
+	//only a file of a previous run can be a compressed current file, point
+	//the logger at the backup to read it like one
+	l.locker.Lock()
+	l.curFile = backups[0]
+	l.locker.Unlock()
+	if got, next, overflow, err := l.ReadTailLog(90, 100); err != nil || got != content[90:] || next != 100 || overflow {
+		t.Errorf("ReadTailLog = %q, %d, %v, %v, want the decompressed tail", got, next, overflow, err)
+	}
+}
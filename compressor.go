@@ -0,0 +1,118 @@
+package core
+
+import (
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Compressor is a codec for the rotated log files. A compressed file is
+// named after the log file with the Extension of its codec, like ".gz", and
+// is decompressed by the reads with the Compressor registered for its
+// extension, so codecs with heavy dependencies like zstd stay out of the
+// package
+type Compressor interface {
+	// the suffix of the compressed files, with its dot
+	Extension() string
+	Compress(dst io.Writer, src io.Reader) error
+	Decompress(src io.Reader) (io.ReadCloser, error)
+}
+
+// GzipCompressor compresses to .gz files, it is the default Compressor
+type GzipCompressor struct {
+}
+
+func (GzipCompressor) Extension() string {
+	return ".gz"
+}
+
+func (GzipCompressor) Compress(dst io.Writer, src io.Reader) error {
+	zw := gzip.NewWriter(dst)
+	_, err := io.Copy(zw, src)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (GzipCompressor) Decompress(src io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(src)
+}
+
+// the codecs the reads know, by extension
+var (
+	compressorsLock sync.RWMutex
+	compressors     = map[string]Compressor{".gz": GzipCompressor{}}
+)
+
+// RegisterCompressor makes the reads decompress the files with the
+// extension of c, in place of the Compressor registered for it before.
+// WithCompressor registers its Compressor
+func RegisterCompressor(c Compressor) {
+	compressorsLock.Lock()
+	defer compressorsLock.Unlock()
+
+	compressors[c.Extension()] = c
+}
+
+// WithCompressor compresses every rotated file with c in the background,
+// like WithCompress does with gzip
+func WithCompressor(c Compressor) Option {
+	return func(l *FileLogger) {
+		RegisterCompressor(c)
+		l.compressor = c
+		l.compress = true
+	}
+}
+
+// return the Compressor of a compressed file name, the one of the longest
+// matching extension
+func compressorFor(fileName string) (Compressor, bool) {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+
+	var found Compressor
+	for ext, c := range compressors {
+		if strings.HasSuffix(fileName, ext) && (found == nil || len(ext) > len(found.Extension())) {
+			found = c
+		}
+	}
+	return found, found != nil
+}
+
+// return the extensions of the registered codecs
+func compressExtensions() []string {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+
+	exts := make([]string, 0, len(compressors))
+	for ext := range compressors {
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// return the extension of a compressed file name, or "" for a plain file
+func compressedExt(fileName string) string {
+	if c, ok := compressorFor(fileName); ok {
+		return c.Extension()
+	}
+	return ""
+}
+
+// return a file name without the extension of its codec
+func trimCompressed(fileName string) string {
+	return strings.TrimSuffix(fileName, compressedExt(fileName))
+}
+
+// return the name of the compressed version of a plain log file found on
+// disk, with any registered codec, or fileName itself if there is none
+func findCompressed(fs FS, fileName string) string {
+	for _, ext := range compressExtensions() {
+		if _, err := fs.Stat(fileName + ext); err == nil {
+			return fileName + ext
+		}
+	}
+	return fileName
+}
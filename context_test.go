@@ -0,0 +1,55 @@
+package core_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestWriteContextCancelled(t *testing.T) {
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := l.WriteContext(ctx, []byte("lost\n")); n != 0 || err != context.Canceled {
+		t.Errorf("WriteContext = %d, %v, want 0 and context.Canceled", n, err)
+	}
+	if n, err := l.WriteContext(context.Background(), []byte("kept\n")); n != 5 || err != nil {
+		t.Errorf("WriteContext = %d, %v, want 5 and no error", n, err)
+	}
+	if got, _ := l.ReadLog(0, 0); got != "kept\n" {
+		t.Errorf("ReadLog = %q, want only the write with a live context", got)
+	}
+}
+
+func TestWriteContextDeadline(t *testing.T) {
+	locker := &sync.Mutex{}
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithLocker(locker))
+
+	//the write waits for the lock held here until the deadline
+	locker.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p := []byte("late\n")
+	n, err := l.WriteContext(ctx, p)
+	if n != 0 || err != context.DeadlineExceeded {
+		t.Errorf("WriteContext = %d, %v, want 0 and context.DeadlineExceeded", n, err)
+	}
+	//the write goes on in the background with its own copy of p
+	copy(p, "XXXX\n")
+	locker.Unlock()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := l.ReadLog(0, 0)
+		if got == "late\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ReadLog = %q, want the write completed in the background", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
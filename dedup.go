@@ -0,0 +1,240 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupLogger collapses consecutive identical records: the first one is
+// forwarded to an underlying Logger and the repeats are only counted, then
+// reported by a "last message repeated N times" line. The line is written
+// before the next different record, on Close, after WithDedupMaxRepeats
+// repeats and every WithDedupInterval. The read and clear methods go
+// straight to the underlying logger
+type DedupLogger struct {
+	logger     Logger
+	maxRepeats int
+	interval   time.Duration
+
+	lock    sync.Mutex
+	last    []byte
+	repeats int
+	closed  bool
+	// the first error of a write made by the timer
+	err  error
+	stop chan struct{}
+	done chan struct{}
+}
+
+// DedupOption configures a DedupLogger
+type DedupOption func(*DedupLogger)
+
+// WithDedupMaxRepeats reports the repeats once there are n of them, 0, the
+// default, waits for a different record
+func WithDedupMaxRepeats(n int) DedupOption {
+	return func(l *DedupLogger) {
+		l.maxRepeats = n
+	}
+}
+
+// WithDedupInterval reports the repeats counted so far every d, 0, the
+// default, never reports them on a timer
+func WithDedupInterval(d time.Duration) DedupOption {
+	return func(l *DedupLogger) {
+		l.interval = d
+	}
+}
+
+// NewDedupLogger creates a DedupLogger over logger
+func NewDedupLogger(logger Logger, opts ...DedupOption) *DedupLogger {
+	l := &DedupLogger{logger: logger,
+		stop: make(chan struct{}),
+		done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.interval > 0 {
+		go l.run()
+	} else {
+		close(l.done)
+	}
+	return l
+}
+
+// report the repeats every interval until the logger is closed
+func (l *DedupLogger) run() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.lock.Lock()
+			if err := l.writeRepeats(); err != nil && l.err == nil {
+				l.err = err
+			}
+			l.lock.Unlock()
+		}
+	}
+}
+
+// write the number of repeats of the last record, if any. The caller must
+// hold the lock
+func (l *DedupLogger) writeRepeats() error {
+	if l.repeats == 0 {
+		return nil
+	}
+	_, err := l.logger.Write([]byte(fmt.Sprintf("last message repeated %d times\n", l.repeats)))
+	l.repeats = 0
+	return err
+}
+
+// Write counts p if it is the same as the last record and forwards it
+// otherwise. A counted record still reports len(p)
+func (l *DedupLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	if l.last != nil && bytes.Equal(p, l.last) {
+		l.repeats++
+		if l.maxRepeats > 0 && l.repeats >= l.maxRepeats {
+			if err := l.writeRepeats(); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if err := l.writeRepeats(); err != nil {
+		return 0, err
+	}
+	l.last = append(l.last[:0], p...)
+	return l.logger.Write(p)
+}
+
+func (l *DedupLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+// WriteLines compares each line, ended by a newline, with the last record
+// like Write does. The lines kept are forwarded in batches, split where the
+// repeats of a line must be reported first. A counted line is still
+// counted in the length returned
+func (l *DedupLogger) WriteLines(ss []string) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	kept := make([]string, 0, len(ss))
+	total := 0
+	for _, s := range ss {
+		p := terminateLine(s)
+		total += len(p)
+		if l.last != nil && bytes.Equal(p, l.last) {
+			l.repeats++
+			if l.maxRepeats > 0 && l.repeats >= l.maxRepeats {
+				if err := l.writeLines(kept); err != nil {
+					return 0, err
+				}
+				kept = kept[:0]
+				if err := l.writeRepeats(); err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+		if l.repeats > 0 {
+			if err := l.writeLines(kept); err != nil {
+				return 0, err
+			}
+			kept = kept[:0]
+			if err := l.writeRepeats(); err != nil {
+				return 0, err
+			}
+		}
+		l.last = append(l.last[:0], p...)
+		kept = append(kept, s)
+	}
+	if err := l.writeLines(kept); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// forward a batch of lines if there is any, the caller must hold the lock
+func (l *DedupLogger) writeLines(ss []string) error {
+	if len(ss) == 0 {
+		return nil
+	}
+	_, err := l.logger.WriteLines(ss)
+	return err
+}
+
+// Close reports the last repeats, then closes the underlying logger. The
+// first error of a write made by the timer is returned if there is no other
+func (l *DedupLogger) Close() error {
+	l.lock.Lock()
+	if l.closed {
+		l.lock.Unlock()
+		return ErrLoggerClosed
+	}
+	l.closed = true
+	l.lock.Unlock()
+
+	close(l.stop)
+	<-l.done
+	l.lock.Lock()
+	err := l.writeRepeats()
+	if err == nil {
+		err = l.err
+	}
+	l.lock.Unlock()
+	if e := l.logger.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (l *DedupLogger) ReadLog(offset int64, length int64) (string, error) {
+	return l.logger.ReadLog(offset, length)
+}
+
+func (l *DedupLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return l.logger.ReadTailLog(offset, length)
+}
+
+func (l *DedupLogger) ReadTailLines(n int) ([]string, error) {
+	return l.logger.ReadTailLines(n)
+}
+
+func (l *DedupLogger) LineCount() (int64, error) {
+	return l.logger.LineCount()
+}
+
+func (l *DedupLogger) Stats() LoggerStats {
+	return l.logger.Stats()
+}
+
+func (l *DedupLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func (l *DedupLogger) ClearCurLogFile() error {
+	return l.logger.ClearCurLogFile()
+}
+
+func (l *DedupLogger) ClearAllLogFile() error {
+	return l.logger.ClearAllLogFile()
+}
+
+func (l *DedupLogger) ClearOldestBackup() (string, error) {
+	return l.logger.ClearOldestBackup()
+}
@@ -0,0 +1,62 @@
+package core_test
+
+import (
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestDedupWriteLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []core.DedupOption
+		batches [][]string
+		want    string
+	}{
+		{
+			name:    "repeats in a batch",
+			batches: [][]string{{"a", "a", "a", "b", "b", "c"}},
+			want:    "a\nlast message repeated 2 times\nb\nlast message repeated 1 times\nc\n",
+		},
+		{
+			name:    "repeats across batches",
+			batches: [][]string{{"a", "a"}, {"a\n", "b"}},
+			want:    "a\nlast message repeated 2 times\nb\n",
+		},
+		{
+			name:    "max repeats",
+			opts:    []core.DedupOption{core.WithDedupMaxRepeats(2)},
+			batches: [][]string{{"a", "a", "a", "a", "a", "b"}},
+			want:    "a\nlast message repeated 2 times\nlast message repeated 2 times\nb\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := core.NewMemoryLogger()
+			l := core.NewDedupLogger(mem, tt.opts...)
+			for _, batch := range tt.batches {
+				if _, err := l.WriteLines(batch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := mem.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupWriteLinesLength(t *testing.T) {
+	l := core.NewDedupLogger(core.NewMemoryLogger())
+	n, err := l.WriteLines([]string{"a", "a\n", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 {
+		t.Errorf("n = %d, want 6", n)
+	}
+	l.Close()
+	if _, err := l.WriteLines([]string{"c"}); err != core.ErrLoggerClosed {
+		t.Errorf("WriteLines after Close = %v, want ErrLoggerClosed", err)
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// encryptMagic starts every encrypted log file
+const encryptMagic = "FLGCM1\n"
+
+// ErrEncryptionKeyRequired is returned when reading or appending to an
+// encrypted log file without the key
+var ErrEncryptionKeyRequired = errors.New("log file is encrypted and no key was given")
+
+// errNotEncrypted is returned when a logger with a key finds a plain log
+// file to append to, the logger then rotates to a new file
+var errNotEncrypted = errors.New("log file is not encrypted")
+
+// WithEncryptionKey encrypts the log files with AES-GCM and key, which is
+// 16, 24 or 32 bytes long, and decrypts them on read. Every record is
+// sealed on its own, so the files can still be appended to. The logger
+// reads a file whole to decrypt it: offsets count the decrypted bytes, and
+// a read costs the size of the file whatever the range read. maxSize counts
+// the decrypted bytes too, each record takes 32 more bytes on disk. Follow
+// and LogHandler send the encrypted bytes. A bad key makes the constructor
+// return a BAD_ARGUMENTS Fault
+func WithEncryptionKey(key []byte) Option {
+	return func(l *FileLogger) {
+		block, err := aes.NewCipher(key)
+		if err == nil {
+			l.aead, err = cipher.NewGCM(block)
+		}
+		l.aeadErr = err
+	}
+}
+
+// encryptWriter seals every write to w as a frame: the length of the
+// sealed record on 4 bytes, the nonce and the sealed record
+type encryptWriter struct {
+	w    io.Writer
+	aead cipher.AEAD
+}
+
+func (e encryptWriter) Write(p []byte) (int, error) {
+	frame := make([]byte, 4+e.aead.NonceSize(), 4+e.aead.NonceSize()+len(p)+e.aead.Overhead())
+	if _, err := rand.Read(frame[4:]); err != nil {
+		return 0, err
+	}
+	frame = e.aead.Seal(frame, frame[4:], p, nil)
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4-e.aead.NonceSize()))
+	if _, err := e.w.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// check if the start of a file is the one of an encrypted file
+func isEncrypted(head []byte) bool {
+	return bytes.HasPrefix(head, []byte(encryptMagic))
+}
+
+// return the records of an encrypted file. A frame cut by a crash at the
+// end is dropped, and the magic found between frames, as in merged files,
+// is skipped
+func decryptAll(b []byte, aead cipher.AEAD) ([]byte, error) {
+	if aead == nil {
+		return nil, ErrEncryptionKeyRequired
+	}
+	out := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		if bytes.HasPrefix(b, []byte(encryptMagic)) {
+			b = b[len(encryptMagic):]
+			continue
+		}
+		if len(b) < 4+aead.NonceSize() {
+			break
+		}
+		//the length is checked before it becomes an int, which may not
+		//hold it on 32 bits
+		size := binary.BigEndian.Uint32(b)
+		b = b[4:]
+		if uint64(size) > uint64(len(b)-aead.NonceSize()) {
+			break
+		}
+		n := int(size)
+		nonce := b[:aead.NonceSize()]
+		record, err := aead.Open(out[len(out):], nonce, b[aead.NonceSize():aead.NonceSize()+n], nil)
+		if err != nil {
+			return nil, err
+		}
+		out = out[:len(out)+len(record)]
+		b = b[aead.NonceSize()+n:]
+	}
+	return out, nil
+}
+
+// decrypt a log file opened for random access reads if it is encrypted
+func decryptRange(f rangeFile, size int64, aead cipher.AEAD) (rangeFile, int64, error) {
+	head := make([]byte, len(encryptMagic))
+	if n, _ := f.ReadAt(head, 0); !isEncrypted(head[:n]) {
+		return f, size, nil
+	}
+	defer f.Close()
+	b := make([]byte, size)
+	if _, err := f.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	b, err := decryptAll(b, aead)
+	if err != nil {
+		return nil, 0, err
+	}
+	return memFile{bytes.NewReader(b)}, int64(len(b)), nil
+}
+
+// bufferedReadCloser reads through a bufio.Reader and closes its source
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// decrypt a log file opened for reading if it is encrypted
+func decryptReader(r io.ReadCloser, aead cipher.AEAD) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(encryptMagic)); !isEncrypted(head) {
+		return bufferedReadCloser{Reader: br, Closer: r}, nil
+	}
+	defer r.Close()
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = decryptAll(b, aead); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// start a new log file with the magic of the encrypted files, the caller
+// must hold the lock
+func (l *FileLogger) writeEncryptMagic() error {
+	w := io.Writer(l.file)
+	if l.buf != nil {
+		w = l.buf
+	}
+	n, err := w.Write([]byte(encryptMagic))
+	l.fileSize += int64(n)
+	return err
+}
+
+// check that a log file opened to append to is encrypted if and only if
+// the logger has a key, an empty file is started as a new one. The file is
+// closed by openFile on error
+func (l *FileLogger) checkEncryption() error {
+	head := make([]byte, len(encryptMagic))
+	n, _ := l.file.ReadAt(head, 0)
+	switch {
+	case l.aead == nil && isEncrypted(head[:n]):
+		return ErrEncryptionKeyRequired
+	case l.aead != nil && n == 0:
+		return l.writeEncryptMagic()
+	case l.aead != nil && !isEncrypted(head[:n]):
+		return errNotEncrypted
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef")
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	l := newTestLogger(t, 0, 3, WithEncryptionKey(testKey))
+	l.Write([]byte("secret one\n"))
+	l.Write([]byte("secret two\n"))
+	if s, err := l.ReadLog(0, 0); err != nil || s != "secret one\nsecret two\n" {
+		t.Fatalf("ReadLog returned %q %v", s, err)
+	}
+	raw := readTestFile(t, l.GetCurrentLogFile())
+	if !strings.HasPrefix(raw, encryptMagic) || strings.Contains(raw, "secret") {
+		t.Fatalf("file not encrypted: %q", raw)
+	}
+}
+
+func TestEncryptionBadKey(t *testing.T) {
+	_, err := NewFileLoggerE(filepath.Join(t.TempDir(), "test.log"), 0, 3, nil, WithEncryptionKey([]byte("short")))
+	if err == nil {
+		t.Fatal("no error for a bad key")
+	}
+}
+
+func TestEncryptionRequiresKey(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := NewFileLogger(name, 0, 3, nil, WithEncryptionKey(testKey))
+	l.Write([]byte("secret\n"))
+	l.Close()
+	if _, err := ReadFile(name+".0", 0, 0); err == nil {
+		t.Fatal("encrypted file read without the key")
+	}
+}
+
+func TestEncryptionRotatesPlainFile(t *testing.T) {
+	for _, c := range []struct {
+		strategy RotationStrategy
+		current  string
+		backup   string
+	}{{RotateRing, ".1", ".0"}, {RotateShift, "", ".1"}} {
+		name := filepath.Join(t.TempDir(), "test.log")
+		plain := NewFileLogger(name, 0, 3, nil, WithRotationStrategy(c.strategy))
+		plain.Write([]byte("plain\n"))
+		plain.Close()
+
+		var reported error
+		l, err := NewFileLoggerE(name, 0, 3, nil, WithEncryptionKey(testKey),
+			WithRotationStrategy(c.strategy),
+			WithErrorHandler(func(err error) { reported = err }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reported != errNotEncrypted {
+			t.Fatalf("mismatch reported as %v", reported)
+		}
+		if _, err := l.Write([]byte("secret\n")); err != nil {
+			t.Fatal(err)
+		}
+		if l.GetCurrentLogFile() != name+c.current {
+			t.Fatalf("current file is %s", l.GetCurrentLogFile())
+		}
+		if s, err := l.ReadLog(0, 0); err != nil || s != "secret\n" {
+			t.Fatalf("ReadLog returned %q %v", s, err)
+		}
+		if s := readTestFile(t, name+c.backup); s != "plain\n" {
+			t.Fatalf("plain file holds %q", s)
+		}
+		l.Close()
+	}
+}
+
+func TestDecryptAllHugeLength(t *testing.T) {
+	block, _ := aes.NewCipher(testKey)
+	aead, _ := cipher.NewGCM(block)
+	var buf bytes.Buffer
+	buf.WriteString(encryptMagic)
+	encryptWriter{w: &buf, aead: aead}.Write([]byte("kept\n"))
+	frame := make([]byte, 4+aead.NonceSize()+8)
+	binary.BigEndian.PutUint32(frame, 0xffffffff)
+	buf.Write(frame)
+
+	b, err := decryptAll(buf.Bytes(), aead)
+	if err != nil || string(b) != "kept\n" {
+		t.Fatalf("decryptAll returned %q %v", b, err)
+	}
+}
+
+func TestEncryptionRestartAppends(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	for _, line := range []string{"one\n", "two\n"} {
+		l := NewFileLogger(name, 0, 3, nil, WithEncryptionKey(testKey))
+		l.Write([]byte(line))
+		l.Close()
+	}
+	l := NewFileLogger(name, 0, 3, nil, WithEncryptionKey(testKey))
+	defer l.Close()
+	if s, err := l.ReadLog(0, 0); err != nil || s != "one\ntwo\n" {
+		t.Fatalf("ReadLog returned %q %v", s, err)
+	}
+	if _, err := os.Stat(name + ".1"); !os.IsNotExist(err) {
+		t.Fatal("encrypted file rotated on restart")
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+// test hooks giving the tests of package core_test the rotation and
+// retention decisions alone, locked like the exported methods
+
+func (l *FileLogger) ShouldRotate() bool {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	return l.shouldRotate()
+}
+
+func (l *FileLogger) DoRotate() error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	return l.doRotate()
+}
+
+func (l *FileLogger) RetentionRemovals() ([]string, error) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	return l.retentionRemovals()
+}
+
+func (l *FileLogger) ApplyRetention() error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	return l.applyRetention()
+}
+
+// set the counters of the current file shouldRotate looks at
+func (l *FileLogger) SetFileState(size int64, writes int) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	l.fileSize = size
+	l.fileWrites = writes
+}
@@ -0,0 +1,54 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestWatchExternalChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(file string) error
+	}{
+		{"removed", os.Remove},
+		{"renamed", func(file string) error { return os.Rename(file, file+".moved") }},
+		{"truncated", func(file string) error { return os.Truncate(file, 0) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithWatchExternalChanges(true))
+			write(t, l, "before\n")
+			file := l.GetCurrentLogFile()
+			if err := tt.change(file); err != nil {
+				t.Skipf("can't change an open file: %v", err)
+			}
+			write(t, l, "after\n")
+			if got := readFile(t, file); got != "after\n" {
+				t.Errorf("file %q, want only the write after the change", got)
+			}
+			if got, _ := l.ReadLog(0, 0); got != "after\n" {
+				t.Errorf("ReadLog = %q, want only the write after the change", got)
+			}
+			if n := l.Stats().CurrentFileSize; n != int64(len("after\n")) {
+				t.Errorf("size %d, want the size of the reopened file", n)
+			}
+		})
+	}
+}
+
+func TestWithoutWatchExternalChanges(t *testing.T) {
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"))
+	write(t, l, "before\n")
+	file := l.GetCurrentLogFile()
+	if err := os.Remove(file); err != nil {
+		t.Skipf("can't remove an open file: %v", err)
+	}
+	//the write goes to the removed file, nothing is created again
+	write(t, l, "after\n")
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("%s created again without WithWatchExternalChanges", file)
+	}
+}
@@ -1,6 +1,11 @@
 package core
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
 	xmlrpc "github.com/ochinchina/gorilla-xmlrpc/xml"
 )
 
@@ -25,6 +30,103 @@ const (
 	CANT_REREAD           = 92
 )
 
+// Fault is the error carrying one of the codes above
+type Fault = xmlrpc.Fault
+
 func NewFault(code int, desc string) error {
 	return &xmlrpc.Fault{Code: code, String: desc}
 }
+
+// wrappedFault is a fault carrying the error that caused it
+type wrappedFault struct {
+	*xmlrpc.Fault
+	err error
+}
+
+func (f *wrappedFault) Error() string {
+	return f.Fault.Error() + ": " + f.err.Error()
+}
+
+// Unwrap lets errors.As find the fault and errors.Is match the cause, like
+// os.ErrPermission
+func (f *wrappedFault) Unwrap() []error {
+	return []error{f.Fault, f.err}
+}
+
+// NewFaultWrap returns the fault NewFault would return, carrying err as its
+// cause. A nil err returns a plain fault
+func NewFaultWrap(code int, desc string, err error) error {
+	if err == nil {
+		return NewFault(code, desc)
+	}
+	return &wrappedFault{Fault: &xmlrpc.Fault{Code: code, String: desc}, err: err}
+}
+
+// the stable names of the fault codes
+var faultNames = map[int]string{
+	UNKNOWN_METHOD:        "UNKNOWN_METHOD",
+	INCORRECT_PARAMETERS:  "INCORRECT_PARAMETERS",
+	BAD_ARGUMENTS:         "BAD_ARGUMENTS",
+	SIGNATURE_UNSUPPORTED: "SIGNATURE_UNSUPPORTED",
+	SHUTDOWN_STATE:        "SHUTDOWN_STATE",
+	BAD_NAME:              "BAD_NAME",
+	BAD_SIGNAL:            "BAD_SIGNAL",
+	NO_FILE:               "NO_FILE",
+	NOT_EXECUTABLE:        "NOT_EXECUTABLE",
+	FAILED:                "FAILED",
+	ABNORMAL_TERMINATION:  "ABNORMAL_TERMINATION",
+	SPAWN_ERROR:           "SPAWN_ERROR",
+	ALREADY_STARTED:       "ALREADY_STARTED",
+	NOT_RUNNING:           "NOT_RUNNING",
+	SUCCESS:               "SUCCESS",
+	ALREADY_ADDED:         "ALREADY_ADDED",
+	STILL_RUNNING:         "STILL_RUNNING",
+	CANT_REREAD:           "CANT_REREAD",
+}
+
+// faultJSON is the JSON form of a fault
+type faultJSON struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FaultName returns the stable name of a fault code, like "NO_FILE", to
+// handle the faults programmatically
+func FaultName(code int) string {
+	if name, ok := faultNames[code]; ok {
+		return name
+	}
+	return strconv.Itoa(code)
+}
+
+// MarshalFault renders err as {"code": "...", "message": "..."} where code
+// is the name of the fault code, the cause of a wrapped fault is dropped. An
+// error that is not a fault is rendered as a FAILED fault with the error
+// text as message
+func MarshalFault(err error) ([]byte, error) {
+	f := faultJSON{Code: FaultName(FAILED), Message: err.Error()}
+	var fault *Fault
+	if errors.As(err, &fault) {
+		f = faultJSON{Code: FaultName(fault.Code), Message: fault.String}
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalFault parses a fault rendered by MarshalFault back into the
+// Fault NewFault would have returned
+func UnmarshalFault(b []byte) (*Fault, error) {
+	var f faultJSON
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	for code, name := range faultNames {
+		if name == f.Code {
+			return &Fault{Code: code, String: f.Message}, nil
+		}
+	}
+	code, err := strconv.Atoi(f.Code)
+	if err != nil {
+		return nil, fmt.Errorf("unknown fault code %q", f.Code)
+	}
+	return &Fault{Code: code, String: f.Message}, nil
+}
@@ -0,0 +1,42 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestFaultRoundTrip(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+		msg  string
+	}{
+		{core.NewFault(core.NO_FILE, "NO_FILE"), core.NO_FILE, "NO_FILE"},
+		{core.NewFaultWrap(core.FAILED, "FAILED", errors.New("cause")), core.FAILED, "FAILED"},
+		{core.NewFault(1234, "custom"), 1234, "custom"},
+		{errors.New("plain"), core.FAILED, "plain"},
+	}
+	for _, tt := range tests {
+		b, err := core.MarshalFault(tt.err)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fault, err := core.UnmarshalFault(b)
+		if err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if fault.Code != tt.code || fault.String != tt.msg {
+			t.Errorf("%s: got %d %q, want %d %q", b, fault.Code, fault.String, tt.code, tt.msg)
+		}
+	}
+}
+
+func TestUnmarshalFaultErrors(t *testing.T) {
+	for _, b := range []string{`{"code": "NO_SUCH_CODE"}`, `not json`} {
+		if fault, err := core.UnmarshalFault([]byte(b)); err == nil {
+			t.Errorf("%s: got %v, want an error", b, fault)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"os"
+	"sync"
+)
+
+// FifoLogger writes to a named pipe read by another process, like a
+// sidecar shipping the logs. The pipe is opened without waiting for a
+// reader: while there is none, and when the reader went away, the records
+// are dropped and counted in Dropped rather than failing or blocking the
+// writer. A write that finds the pipe broken reopens it and is tried once
+// more. There is no file to read or clear, those methods return a NO_FILE
+// Fault like StdoutLogger does
+type FifoLogger struct {
+	path string
+
+	lock    sync.Mutex
+	file    *os.File
+	closed  bool
+	written int64
+	dropped int64
+}
+
+// open the pipe if it is not open yet. The caller must hold the lock
+func (l *FifoLogger) open() error {
+	if l.file != nil {
+		return nil
+	}
+	f, err := openFifo(l.path)
+	if err != nil {
+		return err
+	}
+	l.file = f
+	return nil
+}
+
+// close the pipe after its reader went away. The caller must hold the lock
+func (l *FifoLogger) reset() {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// Write sends p to the reader of the pipe, or drops it if there is no
+// reader. A dropped record still reports len(p)
+func (l *FifoLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	for retry := 0; ; retry++ {
+		err := l.open()
+		if err == nil {
+			var n int
+			n, err = l.file.Write(p)
+			l.written += int64(n)
+			if err == nil {
+				return n, nil
+			}
+		}
+		if !fifoGone(err) {
+			return 0, err
+		}
+		l.reset()
+		if retry > 0 {
+			l.dropped++
+			return len(p), nil
+		}
+	}
+}
+
+func (l *FifoLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *FifoLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+// Dropped returns how many records Write discarded for want of a reader
+func (l *FifoLogger) Dropped() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.dropped
+}
+
+func (l *FifoLogger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return ErrLoggerClosed
+	}
+	l.closed = true
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *FifoLogger) ReadLog(offset int64, length int64) (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *FifoLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *FifoLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *FifoLogger) LineCount() (int64, error) {
+	return 0, NewFault(NO_FILE, "NO_FILE")
+}
+
+// Stats returns the bytes sent, CurrentFile is the path of the pipe
+func (l *FifoLogger) Stats() LoggerStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return LoggerStats{BytesWritten: l.written, CurrentFile: l.path}
+}
+
+func (l *FifoLogger) Sync() error {
+	return nil
+}
+
+func (l *FifoLogger) ClearCurLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *FifoLogger) ClearAllLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *FifoLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
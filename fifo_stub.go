@@ -0,0 +1,21 @@
+//go:build !unix
+
+package core
+
+import (
+	"errors"
+	"os"
+)
+
+// NewFifoLogger always fails, there are no named pipes on this system
+func NewFifoLogger(path string) (*FifoLogger, error) {
+	return nil, errors.New("named pipes are not supported on this system")
+}
+
+func openFifo(path string) (*os.File, error) {
+	return nil, errors.New("named pipes are not supported on this system")
+}
+
+func fifoGone(err error) bool {
+	return false
+}
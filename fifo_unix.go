@@ -0,0 +1,36 @@
+//go:build unix
+
+package core
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// NewFifoLogger creates a FifoLogger writing to the named pipe at path,
+// which must exist. The pipe does not need a reader yet
+func NewFifoLogger(path string) (*FifoLogger, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, NewFaultWrap(NO_FILE, "NO_FILE", err)
+	}
+	if fileInfo.Mode()&os.ModeNamedPipe == 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l := &FifoLogger{path: path}
+	if err = l.open(); err != nil && !fifoGone(err) {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open the write end of a named pipe, failing at once if it has no reader
+func openFifo(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|syscall.O_NONBLOCK, 0)
+}
+
+// tell if err means the pipe has no reader
+func fifoGone(err error) bool {
+	return errors.Is(err, syscall.ENXIO) || errors.Is(err, syscall.EPIPE)
+}
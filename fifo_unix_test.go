@@ -0,0 +1,98 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// open the read end of a fifo without waiting for a writer
+func openFifoReader(t *testing.T, path string) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func readFifo(t *testing.T, f *os.File, n int) string {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := f.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFifoLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("can't create a fifo: %v", err)
+	}
+	l, err := NewFifoLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	//no reader yet, the record is dropped without blocking
+	if n, err := l.Write([]byte("lost\n")); err != nil || n != 5 {
+		t.Fatalf("Write = %d, %v without a reader", n, err)
+	}
+	if n := l.Dropped(); n != 1 {
+		t.Fatalf("%d records dropped, want 1", n)
+	}
+
+	reader := openFifoReader(t, path)
+	if _, err := l.WriteLine("first"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFifo(t, reader, 6); got != "first\n" {
+		t.Errorf("read %q, want the record written", got)
+	}
+
+	//the reader goes away, the pipe is reopened once and the record dropped
+	reader.Close()
+	if _, err := l.WriteLine("gone"); err != nil {
+		t.Fatal(err)
+	}
+	if n := l.Dropped(); n != 2 {
+		t.Errorf("%d records dropped, want 2", n)
+	}
+
+	//a new reader gets the next records in order
+	reader = openFifoReader(t, path)
+	defer reader.Close()
+	if _, err := l.WriteLines([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFifo(t, reader, 4); got != "a\nb\n" {
+		t.Errorf("read %q after a new reader", got)
+	}
+	if n := l.Stats().BytesWritten; n != 10 {
+		t.Errorf("%d bytes written, want 10", n)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Write([]byte("closed\n")); err != ErrLoggerClosed {
+		t.Errorf("Write after Close = %v, want ErrLoggerClosed", err)
+	}
+}
+
+func TestNewFifoLoggerErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewFifoLogger(filepath.Join(dir, "missing")); faultCode(err) != NO_FILE {
+		t.Errorf("missing pipe: %v, want NO_FILE", err)
+	}
+	plain := filepath.Join(dir, "plain")
+	os.WriteFile(plain, nil, 0644)
+	if _, err := NewFifoLogger(plain); faultCode(err) != BAD_ARGUMENTS {
+		t.Errorf("plain file: %v, want BAD_ARGUMENTS", err)
+	}
+}
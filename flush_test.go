@@ -0,0 +1,87 @@
+package core_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// a TickerClock whose ticks are sent by the test
+type manualTicker struct {
+	fixedClock
+	lock    sync.Mutex
+	ticks   chan time.Time
+	stopped bool
+}
+
+func (c *manualTicker) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {
+		c.lock.Lock()
+		c.stopped = true
+		c.lock.Unlock()
+	}
+}
+
+// wait for f to be true, failing after a few seconds
+func eventually(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	clock := &manualTicker{fixedClock: fixedClock{time.Now()}, ticks: make(chan time.Time)}
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithBufferSize(4096), core.WithFlushInterval(time.Second), core.WithClock(clock))
+	write(t, l, "buffered\n")
+	if got := readFile(t, l.GetCurrentLogFile()); got != "" {
+		t.Fatalf("file %q before the tick, want the record still buffered", got)
+	}
+	//the send returns once the loop took the tick, the flush follows
+	clock.ticks <- time.Now()
+	eventually(t, "the flush", func() bool { return readFile(t, l.GetCurrentLogFile()) == "buffered\n" })
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	if !clock.stopped {
+		t.Error("Close did not stop the ticker")
+	}
+}
+
+func TestFlushLoopGoroutines(t *testing.T) {
+	dir := t.TempDir()
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		l, err := core.NewFileLoggerWithOptions(filepath.Join(dir, "test.log"),
+			core.WithBufferSize(4096), core.WithFlushInterval(time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(t, l, "record\n")
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	eventually(t, "the flush loops to end", func() bool { return runtime.NumGoroutine() <= before })
+	//no flush loop without a buffer
+	l, err := core.NewFileLoggerWithOptions(filepath.Join(dir, "plain.log"), core.WithFlushInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines running for an unbuffered logger, want %d", n, before)
+	}
+}
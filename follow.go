@@ -0,0 +1,330 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// how often a follower checks for new data once it reached the end of file
+const followPollInterval = 200 * time.Millisecond
+
+// TailEventType is the kind of a TailEvent
+type TailEventType int
+
+const (
+	// TailLine carries a line appended to the log
+	TailLine TailEventType = iota
+	// TailRotated tells the stream moved to File after a rotation
+	TailRotated
+	// TailDone ends the stream normally: ctx is done or the logger is closed
+	TailDone
+	// TailError ends the stream because of Err
+	TailError
+	// TailTruncated tells the current file was truncated in place, by
+	// ClearCurLogFile for example, and is read again from its beginning
+	TailTruncated
+)
+
+// TailEvent is an event of a FollowEvents stream
+type TailEvent struct {
+	Type TailEventType
+	// Line is the line without its newline for a TailLine event
+	Line string
+	// File is the new log file for a TailRotated event, or the truncated one
+	// for a TailTruncated event
+	File string
+	// Err is the read error for a TailError event
+	Err error
+}
+
+// follower streams the lines appended to the log files of a FileLogger
+type follower struct {
+	logger  *FileLogger
+	ctx     context.Context
+	events  chan TailEvent
+	name    string
+	file    *os.File
+	reader  *bufio.Reader
+	pending []byte
+}
+
+// Follow streams the lines appended to the log from now on, like
+// FollowWithReplay with no replay
+func (l *FileLogger) Follow(ctx context.Context) (<-chan string, error) {
+	return l.FollowWithReplay(ctx, 0)
+}
+
+// FollowWithReplay streams the lines written to the log as FollowEvents does,
+// with only the lines of the TailLine events and without their
+// trailing newline, like `tail -f`. The last replayBytes bytes already in the
+// current file are sent first, starting at the first complete line, and the
+// new lines follow without gap or duplicate since both come from the same
+// read position. The follower goes on over rotations, a file rotated in and
+// out between two polls is skipped. When the current file is truncated in
+// place, the follower reads it again from the beginning; a truncation
+// followed by more writes than it removed before the next poll is not
+// noticed. The returned channel is closed when ctx
+// is done or the file can't be read anymore.
+//
+// The writer lock is only taken to find the current file, never while
+// waiting for new data
+func (l *FileLogger) FollowWithReplay(ctx context.Context, replayBytes int64) (<-chan string, error) {
+	events, err := l.FollowEvents(ctx, replayBytes)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for ev := range events {
+			if ev.Type != TailLine {
+				continue
+			}
+			select {
+			case lines <- ev.Line:
+			case <-ctx.Done():
+				//let the follower see ctx and stop
+				for range events {
+				}
+				return
+			}
+		}
+	}()
+	return lines, nil
+}
+
+// FollowEvents streams the events of the log like FollowWithReplay streams
+// its lines. The stream is zero or more TailLine, TailRotated and
+// TailTruncated events in order, then one TailDone or TailError event, then
+// the channel is closed. TailDone is sent once the logger is closed and
+// every line written before has been sent, or when ctx is done. Since a
+// consumer cancelling ctx may have stopped reading, the TailDone for a
+// cancelled ctx is only sent if the channel has room, the close of the
+// channel always ends the stream
+func (l *FileLogger) FollowEvents(ctx context.Context, replayBytes int64) (<-chan TailEvent, error) {
+	l.rlock()
+	name := l.currentLogFile()
+	f, err := l.fs.OpenFile(name, os.O_RDONLY, 0)
+	l.runlock()
+	if err != nil {
+		return nil, err
+	}
+
+	statInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	start := statInfo.Size()
+	if replayBytes > 0 {
+		start -= replayBytes
+		if start < 0 {
+			start = 0
+		}
+	}
+	skipPartial := false
+	if start > 0 && start < statInfo.Size() {
+		//the replay starts in the middle of a line unless the previous byte is a newline
+		b := make([]byte, 1)
+		if _, err = f.ReadAt(b, start-1); err != nil {
+			f.Close()
+			return nil, err
+		}
+		skipPartial = b[0] != '\n'
+	}
+	if _, err = f.Seek(start, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	fw := &follower{logger: l,
+		ctx:    ctx,
+		events: make(chan TailEvent, 1),
+		name:   name,
+		file:   f,
+		reader: bufio.NewReader(f)}
+	if skipPartial {
+		if _, err = fw.reader.ReadBytes('\n'); err != nil && err != io.EOF {
+			f.Close()
+			return nil, err
+		}
+	}
+	go fw.run()
+	return fw.events, nil
+}
+
+func (fw *follower) run() {
+	defer close(fw.events)
+	defer func() {
+		fw.file.Close()
+	}()
+
+	for {
+		if err := fw.drain(); err != nil {
+			fw.end(err)
+			return
+		}
+		truncated, err := fw.truncated()
+		if err != nil {
+			fw.end(err)
+			return
+		}
+		if truncated {
+			if !fw.send(TailEvent{Type: TailTruncated, File: fw.name}) {
+				fw.end(fw.ctx.Err())
+				return
+			}
+			continue
+		}
+		rotated, closed, err := fw.rotated()
+		if err != nil {
+			fw.end(err)
+			return
+		}
+		if rotated || closed {
+			//pick up what was written just before the rotation
+			if err = fw.drain(); err != nil {
+				fw.end(err)
+				return
+			}
+			if len(fw.pending) > 0 && !fw.send(TailEvent{Type: TailLine, Line: string(fw.pending)}) {
+				fw.end(fw.ctx.Err())
+				return
+			}
+			fw.pending = nil
+		}
+		//a logger closed right after a rotation still has the new file to read
+		if rotated {
+			if err = fw.switchFile(); err != nil {
+				fw.end(err)
+				return
+			}
+			if !fw.send(TailEvent{Type: TailRotated, File: fw.name}) {
+				fw.end(fw.ctx.Err())
+				return
+			}
+			continue
+		}
+		if closed {
+			fw.end(nil)
+			return
+		}
+		select {
+		case <-fw.ctx.Done():
+			fw.end(fw.ctx.Err())
+			return
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// send the last event of the stream: TailDone if err is nil or the error
+// of ctx, or else TailError
+func (fw *follower) end(err error) {
+	if err != nil && err == fw.ctx.Err() {
+		select {
+		case fw.events <- TailEvent{Type: TailDone}:
+		default:
+		}
+		return
+	}
+	ev := TailEvent{Type: TailDone}
+	if err != nil {
+		ev = TailEvent{Type: TailError, Err: err}
+	}
+	fw.send(ev)
+}
+
+// send all the complete lines up to the end of file, the error is the one of
+// ctx if it is done
+func (fw *follower) drain() error {
+	for {
+		line, err := fw.reader.ReadBytes('\n')
+		fw.pending = append(fw.pending, line...)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fw.send(TailEvent{Type: TailLine, Line: string(bytes.TrimSuffix(fw.pending, []byte{'\n'}))}) {
+			return fw.ctx.Err()
+		}
+		fw.pending = fw.pending[:0]
+	}
+}
+
+// send an event to the channel unless ctx is done first
+func (fw *follower) send(ev TailEvent) bool {
+	select {
+	case fw.events <- ev:
+		return true
+	case <-fw.ctx.Done():
+		return false
+	}
+}
+
+// check if the logger moved to another file since the follower opened its
+// own, or if the logger is closed
+func (fw *follower) rotated() (bool, bool, error) {
+	fw.logger.rlock()
+	name := fw.logger.currentLogFile()
+	closed := fw.logger.closed
+	fw.logger.runlock()
+	if name != fw.name {
+		return true, closed, nil
+	}
+	cur, err := fw.logger.fs.Stat(name)
+	if err != nil {
+		//the file may be between a remove and a create
+		return false, closed, nil
+	}
+	own, err := fw.file.Stat()
+	if err != nil {
+		return false, closed, err
+	}
+	return !os.SameFile(cur, own), closed, nil
+}
+
+// check if the file being read became shorter than the read position, and
+// if so go back to its beginning, dropping the partial line read so far
+func (fw *follower) truncated() (bool, error) {
+	pos, err := fw.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	pos -= int64(fw.reader.Buffered())
+	statInfo, err := fw.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if statInfo.Size() >= pos {
+		return false, nil
+	}
+	if _, err = fw.file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	fw.reader.Reset(fw.file)
+	fw.pending = nil
+	return true, nil
+}
+
+// open the current log file of the logger from its beginning
+func (fw *follower) switchFile() error {
+	fw.logger.rlock()
+	name := fw.logger.currentLogFile()
+	f, err := fw.logger.fs.OpenFile(name, os.O_RDONLY, 0)
+	fw.logger.runlock()
+	if err != nil {
+		return err
+	}
+	fw.file.Close()
+	fw.name = name
+	fw.file = f
+	fw.reader.Reset(f)
+	return nil
+}
@@ -0,0 +1,83 @@
+package core_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// receive the next event of a stream, failing after a few poll intervals
+func nextEvent(t *testing.T, events <-chan core.TailEvent) (core.TailEvent, bool) {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		return ev, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event from the stream")
+	}
+	return core.TailEvent{}, false
+}
+
+func TestFollowEventsCloseWhileStreaming(t *testing.T) {
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithMaxSize(10), core.WithBackups(3))
+	events, err := l.FollowEvents(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, "one\n", "two\n")
+	for _, want := range []string{"one", "two"} {
+		if ev, _ := nextEvent(t, events); ev.Type != core.TailLine || ev.Line != want {
+			t.Fatalf("got %+v, want line %q", ev, want)
+		}
+	}
+
+	//three fills the file, so four is written to the next one and Close
+	//comes while the stream is still running
+	write(t, l, "three\n", "four\n")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for {
+		ev, ok := nextEvent(t, events)
+		if !ok {
+			break
+		}
+		switch ev.Type {
+		case core.TailLine:
+			got = append(got, ev.Line)
+		case core.TailRotated:
+			got = append(got, "rotated")
+		case core.TailDone:
+			got = append(got, "done")
+		default:
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+	if want := []string{"three", "rotated", "four", "done"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFollowEventsCancel(t *testing.T) {
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"))
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := l.FollowEvents(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	for {
+		ev, ok := nextEvent(t, events)
+		if !ok {
+			break
+		}
+		if ev.Type != core.TailDone {
+			t.Fatalf("got %+v after the cancel, want TailDone", ev)
+		}
+	}
+}
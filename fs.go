@@ -0,0 +1,76 @@
+package core
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// FS is the file system a FileLogger creates, reads, lists and removes its
+// log files on. The default one is the os package, tests can provide their
+// own to inject failures. The symbolic link of WithSymlink and the
+// modification time CompactBackups gives a merged file are not covered,
+// they go straight to the os package
+type FS interface {
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	Stat(name string) (os.FileInfo, error)
+	Remove(name string) error
+	Rename(oldpath string, newpath string) error
+	ReadDir(dirname string) ([]os.FileInfo, error)
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// osFS is the FS of the os package
+type osFS struct {
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) Rename(oldpath string, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(dirname)
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Link(oldname string, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+// read a whole file from fs
+func readFile(fs FS, name string) ([]byte, error) {
+	f, err := fs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// linkFS is implemented by the FS that can hard link files, RotateShift
+// then never leaves the current file missing during a rotation
+type linkFS interface {
+	Link(oldname string, newname string) error
+}
+
+// WithFS makes the logger manage its files on fs instead of the os package
+func WithFS(fs FS) Option {
+	return func(l *FileLogger) {
+		l.fs = fs
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// an FS whose opens for reading fail once failReads is set
+type noReadFS struct {
+	osFS
+	failReads bool
+}
+
+func (fs *noReadFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if fs.failReads && flag == os.O_RDONLY {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errors.New("injected failure")}
+	}
+	return fs.osFS.OpenFile(name, flag, perm)
+}
+
+func TestReadsGoThroughFS(t *testing.T) {
+	fs := &noReadFS{}
+	l := newTestLogger(t, 20, 5, WithFS(fs), WithChecksum(true))
+	writeTestLines(t, l, 7, 10)
+	l.compressWG.Wait()
+	tests := []struct {
+		name string
+		read func() error
+	}{
+		{"ReadLog", func() error { _, err := l.ReadLog(0, 0); return err }},
+		{"ReadOlderLog", func() error { _, err := l.ReadOlderLog(1, 0, 0); return err }},
+		{"ReadCombinedLog", func() error { _, err := l.ReadCombinedLog(0, 0); return err }},
+		{"VerifyBackup", func() error { _, err := l.VerifyBackup(1); return err }},
+		{"CompactBackups", func() error { return l.CompactBackups(100) }},
+	}
+	for _, tt := range tests {
+		//a failed compaction leaves the backups as they were
+		fs.failReads = true
+		if err := tt.read(); err == nil {
+			t.Errorf("%s: no error with the reads of the FS failing", tt.name)
+		}
+		fs.failReads = false
+		if err := tt.read(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
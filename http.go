@@ -0,0 +1,128 @@
+package core
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LogHandler serves the log files of a FileLogger over HTTP. The current
+// file is served by default, the `backup` query parameter selects a rotated
+// file the way ReadOlderLog does (1 for the most recent backup).
+//
+// A compressed `.gz` file is sent as is with `Content-Encoding: gzip` to a
+// client accepting gzip, and decompressed for the others, as are the files
+// of the other codecs. A plain file is
+// compressed on the fly for a client accepting gzip if CompressPlain is set
+type LogHandler struct {
+	logger        *FileLogger
+	CompressPlain bool
+}
+
+func NewLogHandler(logger *FileLogger) *LogHandler {
+	return &LogHandler{logger: logger}
+}
+
+func (h *LogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := 0
+	if s := r.URL.Query().Get("backup"); s != "" {
+		var err error
+		n, err = strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "bad backup number", http.StatusBadRequest)
+			return
+		}
+	}
+	f, size, c, err := h.open(n)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer f.Close()
+
+	// the current file may grow while being sent, only send what it had
+	var src io.Reader = io.NewSectionReader(f, 0, size)
+	gzipOK := acceptsGzip(r)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Vary", "Accept-Encoding")
+	_, isGzip := c.(GzipCompressor)
+	switch {
+	case isGzip && gzipOK:
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		io.Copy(w, src)
+	case c != nil:
+		zr, err := c.Decompress(src)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer zr.Close()
+		io.Copy(w, zr)
+	case h.CompressPlain && gzipOK:
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.Copy(zw, src)
+		zw.Close()
+	default:
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		io.Copy(w, src)
+	}
+}
+
+// open the current file if n is 0 or else the nth most recent backup, and
+// return it with its size and its codec, nil if it is not compressed
+func (h *LogHandler) open(n int) (*os.File, int64, Compressor, error) {
+	l := h.logger
+	l.rlock()
+	defer l.runlock()
+
+	fileName := l.currentLogFile()
+	if n > 0 {
+		backups, err := l.listBackups()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if n > len(backups) {
+			return nil, 0, nil, os.ErrNotExist
+		}
+		fileName = backups[len(backups)-n]
+	}
+	f, err := l.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if os.IsNotExist(err) && !isCompressed(fileName) {
+		fileName = findCompressed(l.fs, fileName)
+		f, err = l.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	statInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, nil, err
+	}
+	c, _ := compressorFor(fileName)
+	return f, statInfo.Size(), c, nil
+}
+
+// check if the client accepts a gzip encoded response
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// Labeled is implemented by the loggers carrying labels, like
+// {"component": "auth"}, that the structured sinks attach to each record: a
+// MultiLogger gives the labels of its loggers to the LabeledWriter ones, and
+// NewSlogHandler adds the labels of its logger to every record
+type Labeled interface {
+	Labels() map[string]string
+}
+
+// LabeledWriter is implemented by the structured sinks, like NetLogger, which
+// put the labels of the logger feeding them in each record
+type LabeledWriter interface {
+	WriteLabeled(labels map[string]string, p []byte) (int, error)
+}
+
+// WithLabel attaches labels to the logger, for the structured sinks fed by
+// it to route and filter the records by source. The file output only shows
+// them with WithLabelPrefix
+func WithLabel(labels map[string]string) Option {
+	return func(l *FileLogger) {
+		l.labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			l.labels[k] = v
+		}
+	}
+}
+
+// WithLabelPrefix writes the labels in front of every record in the file,
+// as `key=value` pairs sorted by key and followed by a space
+func WithLabelPrefix(prefix bool) Option {
+	return func(l *FileLogger) {
+		l.labelsInFile = prefix
+	}
+}
+
+// Labels returns a copy of the labels of the logger
+func (l *FileLogger) Labels() map[string]string {
+	labels := make(map[string]string, len(l.labels))
+	for k, v := range l.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// format labels as `key=value` pairs sorted by key, followed by a space, or
+// return nil if there is no label
+func formatLabels(labels map[string]string) []byte {
+	if len(labels) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+		sb.WriteByte(' ')
+	}
+	return []byte(sb.String())
+}
+
+// return the labels of b over the ones of a in a new map, or nil if there
+// is no label
+func mergeLabels(a map[string]string, b map[string]string) map[string]string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		labels[k] = v
+	}
+	for k, v := range b {
+		labels[k] = v
+	}
+	return labels
+}
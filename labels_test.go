@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestLabels(t *testing.T) {
+	labels := map[string]string{"component": "auth", "env": "prod"}
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithLabel(labels))
+	labels["env"] = "dev"
+	got := l.Labels()
+	want := map[string]string{"component": "auth", "env": "prod"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Labels = %v, want %v", got, want)
+	}
+	got["component"] = "billing"
+	if got := l.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels after changing a returned map = %v, want %v", got, want)
+	}
+	if got := newLogger(t, filepath.Join(t.TempDir(), "test.log")).Labels(); len(got) != 0 {
+		t.Errorf("Labels without WithLabel = %v", got)
+	}
+
+	other := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithLabel(map[string]string{"env": "staging", "host": "a"}))
+	m := core.NewMultiLogger([]core.Logger{l, core.NewNullLogger(), other})
+	want = map[string]string{"component": "auth", "env": "staging", "host": "a"}
+	if got := m.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MultiLogger Labels = %v, want %v", got, want)
+	}
+}
+
+func TestLabelsReachNetLogger(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	//two labeled files share the network sink
+	sink := core.NewNetLogger("tcp", ln.Addr().String(), time.Second, 0)
+	dir := t.TempDir()
+	auth := newLogger(t, filepath.Join(dir, "auth.log"), core.WithLabel(map[string]string{"component": "auth", "env": "prod"}))
+	billing := newLogger(t, filepath.Join(dir, "billing.log"), core.WithLabel(map[string]string{"component": "billing"}))
+	authOut := core.NewMultiLogger([]core.Logger{auth, sink})
+	billingOut := core.NewMultiLogger([]core.Logger{billing, sink})
+	for _, w := range []struct {
+		l    *core.MultiLogger
+		line string
+	}{{authOut, "login\n"}, {billingOut, "charge\n"}, {authOut, "logout\n"}} {
+		if n, err := w.l.Write([]byte(w.line)); n != len(w.line) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", w.line, n, err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "component=auth env=prod login\ncomponent=billing charge\ncomponent=auth env=prod logout\n"
+	if got := <-received; got != want {
+		t.Errorf("sink received %q, want %q", got, want)
+	}
+	//the file output has no labels without WithLabelPrefix
+	if got := readFile(t, auth.GetCurrentLogFile()); got != "login\nlogout\n" {
+		t.Errorf("auth file %q, want %q", got, "login\nlogout\n")
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Level is the severity of a record for a LevelLogger
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// the level tokens a record may start with
+var levelTokens = map[string]Level{
+	"[DEBUG]":   LevelDebug,
+	"[INFO]":    LevelInfo,
+	"[WARN]":    LevelWarn,
+	"[WARNING]": LevelWarn,
+	"[ERROR]":   LevelError,
+	"[FATAL]":   LevelFatal,
+}
+
+// LevelLogger drops the records below a threshold level and forwards the
+// others to an underlying Logger. The level of a record is read from its
+// leading token, like [DEBUG] or [INFO], after any spaces; a record without
+// a known token is always forwarded. The read and clear methods go straight
+// to the underlying logger
+type LevelLogger struct {
+	logger Logger
+
+	lock  sync.RWMutex
+	level Level
+}
+
+func NewLevelLogger(logger Logger, level Level) *LevelLogger {
+	return &LevelLogger{logger: logger, level: level}
+}
+
+// SetLevel changes the threshold, the records below level are dropped from
+// now on
+func (l *LevelLogger) SetLevel(level Level) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.level = level
+}
+
+// Level returns the current threshold
+func (l *LevelLogger) Level() Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.level
+}
+
+// return the level of the leading token of p
+func parseLevel(p []byte) (Level, bool) {
+	p = bytes.TrimLeft(p, " \t")
+	if len(p) == 0 || p[0] != '[' {
+		return 0, false
+	}
+	end := bytes.IndexByte(p, ']')
+	if end < 0 {
+		return 0, false
+	}
+	level, ok := levelTokens[string(bytes.ToUpper(p[:end+1]))]
+	return level, ok
+}
+
+// Write forwards p unless its level is below the threshold. A dropped record
+// still reports len(p)
+func (l *LevelLogger) Write(p []byte) (int, error) {
+	if level, ok := parseLevel(p); ok && level < l.Level() {
+		return len(p), nil
+	}
+	return l.logger.Write(p)
+}
+
+func (l *LevelLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+// WriteLines forwards the lines whose level is not below the threshold as
+// one batch. The dropped lines are still counted in the length returned
+func (l *LevelLogger) WriteLines(ss []string) (int, error) {
+	threshold := l.Level()
+	kept := make([]string, 0, len(ss))
+	total := 0
+	for _, s := range ss {
+		total += terminatedLen(s)
+		if level, ok := parseLevel([]byte(s)); ok && level < threshold {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	if len(kept) == 0 {
+		return total, nil
+	}
+	if n, err := l.logger.WriteLines(kept); err != nil {
+		return n, err
+	}
+	return total, nil
+}
+
+func (l *LevelLogger) Close() error {
+	return l.logger.Close()
+}
+
+func (l *LevelLogger) ReadLog(offset int64, length int64) (string, error) {
+	return l.logger.ReadLog(offset, length)
+}
+
+func (l *LevelLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return l.logger.ReadTailLog(offset, length)
+}
+
+func (l *LevelLogger) ReadTailLines(n int) ([]string, error) {
+	return l.logger.ReadTailLines(n)
+}
+
+func (l *LevelLogger) LineCount() (int64, error) {
+	return l.logger.LineCount()
+}
+
+func (l *LevelLogger) Stats() LoggerStats {
+	return l.logger.Stats()
+}
+
+func (l *LevelLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func (l *LevelLogger) ClearCurLogFile() error {
+	return l.logger.ClearCurLogFile()
+}
+
+func (l *LevelLogger) ClearAllLogFile() error {
+	return l.logger.ClearAllLogFile()
+}
+
+func (l *LevelLogger) ClearOldestBackup() (string, error) {
+	return l.logger.ClearOldestBackup()
+}
@@ -0,0 +1,32 @@
+package core_test
+
+import (
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestLevelWriteLines(t *testing.T) {
+	mem := core.NewMemoryLogger()
+	l := core.NewLevelLogger(mem, core.LevelInfo)
+
+	lines := []string{"[DEBUG] a", "[INFO] b", "c", "[debug] d\n", "[ERROR] e"}
+	n, err := l.WriteLines(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len("[DEBUG] a\n[INFO] b\nc\n[debug] d\n[ERROR] e\n"); n != want {
+		t.Errorf("n = %d, want %d", n, want)
+	}
+	if got, want := mem.String(), "[INFO] b\nc\n[ERROR] e\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	//a batch without any line kept writes nothing
+	if _, err := l.WriteLines([]string{"[DEBUG] f"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mem.String(), "[INFO] b\nc\n[ERROR] e\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"bytes"
+	"io"
+)
+
+// how many bytes CountLines reads at a time
+const countChunkSize = 32 << 10
+
+// LineCount returns the number of lines of the current log file. A last line
+// not ended by a newline counts as a line, like for ReadTailLines, so a file
+// of "a\nb" has 2 lines. The file is read in chunks without holding the lock,
+// lines written meanwhile are not counted
+func (l *FileLogger) LineCount() (int64, error) {
+	r, err := l.LogReader(0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return CountLines(r)
+}
+
+// CountLines counts the lines of r with the LineCount rules
+func CountLines(r io.Reader) (int64, error) {
+	buf := getReadBuf(countChunkSize)
+	defer putReadBuf(buf)
+
+	var lines int64
+	var last byte = '\n'
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, NewFaultWrap(FAILED, "FAILED", err)
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"bytes"
+)
+
+// lineLimiter truncates lines longer than max bytes. A line may span
+// several writes, so the length of the current line is carried over
+// between calls
+type lineLimiter struct {
+	max       int64
+	marker    []byte
+	lineLen   int64
+	truncated bool
+}
+
+// WithMaxLineBytes truncates every line longer than n bytes and appends
+// truncateMarker to it. Lines are detected by '\n', so a single write
+// containing several lines only truncates the lines that are too long
+func WithMaxLineBytes(n int64, truncateMarker []byte) Option {
+	return func(l *FileLogger) {
+		if n > 0 {
+			l.lineLimit = &lineLimiter{max: n, marker: truncateMarker}
+		}
+	}
+}
+
+// the marker WithMaxLineLength appends to a truncated line
+const ellipsisMarker = "…"
+
+// WithMaxLineLength truncates every line longer than n bytes like
+// WithMaxLineBytes, marking the truncated lines with an ellipsis. The
+// marker comes on top of the n bytes kept, and 0 keeps the lines whole
+func WithMaxLineLength(n int) Option {
+	return WithMaxLineBytes(int64(n), []byte(ellipsisMarker))
+}
+
+// return p with all the over-length lines truncated, p itself is returned
+// if nothing needs to be truncated
+func (t *lineLimiter) limit(p []byte) []byte {
+	var out []byte
+	copied := 0
+	pos := 0
+	for pos < len(p) {
+		end := len(p)
+		newline := false
+		if i := bytes.IndexByte(p[pos:], '\n'); i >= 0 {
+			end = pos + i
+			newline = true
+		}
+		segLen := int64(end - pos)
+		if t.lineLen+segLen > t.max {
+			keep := t.max - t.lineLen
+			if keep < 0 {
+				keep = 0
+			}
+			if out == nil {
+				out = make([]byte, 0, len(p)+len(t.marker))
+			}
+			out = append(out, p[copied:pos+int(keep)]...)
+			if !t.truncated {
+				out = append(out, t.marker...)
+				t.truncated = true
+			}
+			copied = end
+		}
+		t.lineLen += segLen
+		if newline {
+			t.lineLen = 0
+			t.truncated = false
+			end++
+		}
+		pos = end
+	}
+	if out == nil {
+		return p
+	}
+	return append(out, p[copied:]...)
+}
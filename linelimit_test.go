@@ -0,0 +1,40 @@
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestMaxLineLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		opt    core.Option
+		writes []string
+		want   string
+	}{
+		{"short lines", core.WithMaxLineLength(5), []string{"abc\n", "abcde\n"}, "abc\nabcde\n"},
+		{"long line", core.WithMaxLineLength(5), []string{"abcdefgh\n"}, "abcde…\n"},
+		{"several lines in a write", core.WithMaxLineLength(3), []string{"ab\nabcdef\nabcd\nx\n"}, "ab\nabc…\nabc…\nx\n"},
+		{"line across writes", core.WithMaxLineLength(5), []string{"abc", "def", "ghi\n", "ok\n"}, "abcde…\nok\n"},
+		{"no newline", core.WithMaxLineLength(4), []string{"abcdefgh"}, "abcd…"},
+		{"custom marker", core.WithMaxLineBytes(2, []byte("[cut]")), []string{"abcd\n"}, "ab[cut]\n"},
+		{"empty marker", core.WithMaxLineBytes(2, nil), []string{"abcd\n"}, "ab\n"},
+		{"no limit", core.WithMaxLineLength(0), []string{"abcdefgh\n"}, "abcdefgh\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), tt.opt)
+			for _, w := range tt.writes {
+				n, err := l.Write([]byte(w))
+				if err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v, want the length of the record", w, n, err)
+				}
+			}
+			if got, _ := l.ReadLog(0, 0); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+// WriteLine writes s like Write, ended by a newline added if s has none. The
+// count returned includes the added newline
+func (l *FileLogger) WriteLine(s string) (int, error) {
+	return l.WriteLines([]string{s})
+}
+
+// WriteLines writes each string as a record ended by a newline, like
+// WriteLine, under a single lock so no other write comes between them. The
+// rotation is checked after each record, so a batch larger than the maximum
+// size spreads over several files
+func (l *FileLogger) WriteLines(ss []string) (int, error) {
+	defer l.notifyRotated()
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	total := 0
+	for _, s := range ss {
+		p := terminateLine(s)
+		l.records.Add(1)
+		b, err := l.transform(p)
+		if err != nil {
+			return total, err
+		}
+		n, err := l.write(append(l.prefix(), b)...)
+		n, err = l.written(n, err, len(p))
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// JoinLines joins the strings into one buffer, each ended by a newline added
+// if it has none, for the loggers writing a WriteLines batch at once
+func JoinLines(ss []string) []byte {
+	size := 0
+	for _, s := range ss {
+		size += len(s) + 1
+	}
+	b := make([]byte, 0, size)
+	for _, s := range ss {
+		b = append(b, s...)
+		if len(s) == 0 || s[len(s)-1] != '\n' {
+			b = append(b, '\n')
+		}
+	}
+	return b
+}
+
+// return the length of s ended by a newline
+func terminatedLen(s string) int {
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		return len(s)
+	}
+	return len(s) + 1
+}
+
+// return s ended by a newline
+func terminateLine(s string) []byte {
+	if len(s) > 0 && s[len(s)-1] == '\n' {
+		return []byte(s)
+	}
+	b := make([]byte, len(s)+1)
+	copy(b, s)
+	b[len(s)] = '\n'
+	return b
+}
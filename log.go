@@ -1,24 +1,45 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+	"unsafe"
 )
 
+// ErrFileNotOpen is returned by a write while the logger has no open file,
+// which happens after the log file could not be opened or rotated and the
+// write could not reopen it. It wraps the error of the reopen, and is an
+// invariant violation, see WithPanicOnError
+var ErrFileNotOpen = errors.New("log file is not open")
+
 //implements io.Writer interface
 
 type Logger interface {
 	io.WriteCloser
+	WriteLine(s string) (int, error)
+	WriteLines(ss []string) (int, error)
 	ReadLog(offset int64, length int64) (string, error)
 	ReadTailLog(offset int64, length int64) (string, int64, bool, error)
+	ReadTailLines(n int) ([]string, error)
+	LineCount() (int64, error)
 	ClearCurLogFile() error
 	ClearAllLogFile() error
+	ClearOldestBackup() (string, error)
+	Stats() LoggerStats
+	Sync() error
 }
 
 type FileLogger struct {
@@ -29,6 +50,98 @@ type FileLogger struct {
 	fileSize  int64
 	file      *os.File
 	locker    sync.Locker
+	footer    func() []byte
+	header    func() []byte
+	lineLimit *lineLimiter
+	// permission of the created log files and directories
+	fileMode os.FileMode
+	dirMode  os.FileMode
+	// keep the permission of the current file on rotation
+	preserveMode bool
+	// number of Write calls since construction
+	records atomic.Int64
+	clock   Clock
+	fs      FS
+	// rotate after this number of writes to the current file, for tests
+	forceRotateAfter int
+	fileWrites       int
+	// called with the errors the caller can't see, like a failed rotation
+	errorHandler func(error)
+	// panic on an invariant violation instead of returning an error
+	panicOnError bool
+	// check the writes are valid UTF-8, and fix them if replaceUTF8 is set
+	validateUTF8 bool
+	replaceUTF8  bool
+	// the error of the last write or rotation, nil if it succeeded
+	lastErr atomic.Pointer[error]
+	// buffer the writes to the file if bufSize > 0
+	bufSize        int
+	buf            *bufio.Writer
+	flushOnNewline bool
+	// flush the buffer every flushInterval until flushStop is closed
+	flushInterval time.Duration
+	flushStop     chan struct{}
+	flushDone     chan struct{}
+	// set by Close
+	closed   bool
+	strategy RotationStrategy
+	// the current file and the time in its name for RotateTimestamp
+	curFile  string
+	curStamp time.Time
+	// number of PinCurrent calls not yet released
+	pins int
+	// labels of the logger, written before each record if labelsInFile
+	labels       map[string]string
+	labelsInFile bool
+	labelPrefix  []byte
+	// compress the rotated files in the background
+	compress    bool
+	compressing map[string]*compressJob
+	compressWG  sync.WaitGroup
+	compressSeq int
+	compressor  Compressor
+	// rotate a file once it is older than rotateInterval
+	rotateInterval time.Duration
+	fileCreated    time.Time
+	// reopen the file if it was removed or truncated under the logger
+	watchExternal bool
+	// the most bytes all the log files may take, 0 for no limit
+	maxTotalSize int64
+	// remove the backups older than maxAge, 0 for no limit
+	maxAge time.Duration
+	// counters returned by Stats
+	rotations    int64
+	bytesWritten int64
+	// called with the files rotated out, after the lock is released
+	onRotate   func(oldFile string)
+	rotatedOut []string
+	notifyLock sync.Mutex
+	// the channel returned by RotationEvents, nil until it is called
+	rotationEvents chan RotationEvent
+	// fsync the file after every write
+	syncOnWrite bool
+	// write the time before every record
+	timestampPrefix bool
+	timestampLayout string
+	// remove the ANSI escape sequences from the records
+	stripANSI *ansiStripper
+	// keep a symlink at name to the current file
+	symlink bool
+	// empty the current file at construction
+	truncateOnStart bool
+	// write a checksum sidecar for every rotated file
+	checksum     bool
+	checksumming map[string]*checksumJob
+	// encrypt the log files, aeadErr is the error of a bad key
+	aead    cipher.AEAD
+	aeadErr error
+	// run after every rotation
+	postRotate        []string
+	postRotateTimeout time.Duration
+	// serve the reads of the current file from its mapping
+	mmap     bool
+	mmapLock sync.Mutex
+	mapping  *mapping
 }
 
 type NullLogger struct {
@@ -37,44 +150,139 @@ type NullLogger struct {
 type NullLocker struct {
 }
 
-func NewFileLogger(name string, maxSize int64, backups int, locker sync.Locker) *FileLogger {
-	logger := &FileLogger{name: name,
-		maxSize:   maxSize,
-		backups:   backups,
-		curRotate: -1,
-		fileSize:  0,
-		file:      nil,
-		locker:    locker}
-	logger.updateLatestLog()
+// NewFileLogger creates a FileLogger, creating its directory if needed. An
+// initialization failure goes to the error handler and leaves the logger
+// without a file, use NewFileLoggerE to get it. A maxSize of 0 never
+// rotates by size, and 0 backups never rotates at all: the log then grows
+// in a single file without bound.
+//
+// Every method of the logger takes locker, which is the only lock of its
+// state: the same locker must be used for the whole life of the logger and
+// by nothing that already holds it. A nil locker is replaced by a new
+// sync.RWMutex, and NewNullLocker suits a logger used by a single goroutine.
+// With a locker that also has RLock and RUnlock, like sync.RWMutex, the
+// reads of the current file run together and only wait for a write, a
+// rotation or a truncation
+func NewFileLogger(name string, maxSize int64, backups int, locker sync.Locker, opts ...Option) *FileLogger {
+	logger, _ := NewFileLoggerE(name, maxSize, backups, locker, opts...)
 	return logger
 }
 
+// NewFileLoggerDefault creates a FileLogger like NewFileLogger, locked by a
+// sync.RWMutex of its own
+func NewFileLoggerDefault(name string, maxSize int64, backups int, opts ...Option) *FileLogger {
+	return NewFileLogger(name, maxSize, backups, &sync.RWMutex{}, opts...)
+}
+
+// NewFileLoggerE creates a FileLogger like NewFileLogger and returns the
+// error met while creating the directory or opening the first file. The
+// logger is returned even on error
+func NewFileLoggerE(name string, maxSize int64, backups int, locker sync.Locker, opts ...Option) (*FileLogger, error) {
+	return NewFileLoggerWithOptions(name, append([]Option{WithMaxSize(maxSize),
+		WithBackups(backups),
+		WithLocker(locker)}, opts...)...)
+}
+
+// NewFileLoggerWithOptions creates a FileLogger configured by opts only, and
+// returns the error met while creating the directory or opening the first
+// file like NewFileLoggerE; the logger is returned even on error. Without
+// WithMaxSize and WithBackups the logger keeps 5 files of 10MB, without
+// WithLocker it is locked by a sync.RWMutex of its own
+func NewFileLoggerWithOptions(name string, opts ...Option) (*FileLogger, error) {
+	logger := &FileLogger{name: name,
+		maxSize:     defaultMaxSize,
+		backups:     defaultBackups,
+		curRotate:   -1,
+		fileSize:    0,
+		file:        nil,
+		fileMode:    0644,
+		dirMode:     0755,
+		clock:       realClock{},
+		fs:          osFS{},
+		compressing: make(map[string]*compressJob)}
+	for _, opt := range opts {
+		opt(logger)
+	}
+	if logger.locker == nil {
+		logger.locker = &sync.RWMutex{}
+	}
+	if logger.aeadErr != nil {
+		err := NewFaultWrap(BAD_ARGUMENTS, "BAD_ARGUMENTS", logger.aeadErr)
+		logger.handleError(err)
+		return logger, err
+	}
+	if logger.labelsInFile {
+		logger.labelPrefix = formatLabels(logger.labels)
+	}
+	if err := logger.fs.MkdirAll(filepath.Dir(name), logger.dirMode); err != nil {
+		logger.handleError(err)
+		return logger, err
+	}
+	err := logger.updateLatestLog()
+	if err == nil && logger.truncateOnStart {
+		if err = logger.openFile(true); err != nil {
+			logger.handleError(err)
+		}
+	}
+	if logger.bufSize > 0 && logger.flushInterval > 0 {
+		logger.startFlushLoop()
+	}
+	return logger, err
+}
+
 // return the next log file name
 func (l *FileLogger) nextLogFile() {
-	l.curRotate++
-	if l.curRotate >= l.backups {
-		l.curRotate = 0
+	if l.strategy == RotateTimestamp {
+		l.nextTimestampLogFile()
+		return
 	}
+	//the RotateShift current file keeps its name, shiftLogFiles moves the others
+	if l.strategy == RotateShift {
+		return
+	}
+	l.curRotate = l.nextRotate()
 }
 
-func (l *FileLogger) updateLatestLog() {
-	dir := path.Dir(l.name)
-	files, err := ioutil.ReadDir(dir)
+// return the rotate index following the current one
+func (l *FileLogger) nextRotate() int {
+	i := l.curRotate + 1
+	if i >= l.ringSize() {
+		i = 0
+	}
+	return i
+}
+
+func (l *FileLogger) updateLatestLog() error {
+	if l.strategy == RotateTimestamp {
+		return l.updateLatestTimestampLog()
+	}
+	if l.strategy == RotateShift {
+		return l.updateLatestShiftLog()
+	}
+	dir := filepath.Dir(l.name)
+	files, err := l.fs.ReadDir(dir)
 
 	if err != nil {
+		//without the history start with the first file, so only a failed
+		//open leaves the logger without a file
 		l.curRotate = 0
+		l.handleError(err)
+		if err = l.openCurrent(); err != nil {
+			l.handleError(err)
+		}
 	} else {
 		//find all the rotate files
 		var latestFile os.FileInfo
 		latestNum := -1
 		for _, fileInfo := range files {
-			if strings.HasPrefix(fileInfo.Name(), l.name+".") {
-				n, err := strconv.Atoi(fileInfo.Name()[len(l.name)+1:])
-				if err == nil && n >= 0 && n < l.backups {
-					if latestFile == nil || latestFile.ModTime().Before(fileInfo.ModTime()) {
-						latestFile = fileInfo
-						latestNum = n
-					}
+			//a compressed file is never the current one
+			if isCompressed(fileInfo.Name()) {
+				continue
+			}
+			if n, ok := l.rotateIndex(fileInfo); ok {
+				if latestFile == nil || l.newerRingFile(fileInfo, n, latestFile, latestNum) {
+					latestFile = fileInfo
+					latestNum = n
 				}
 			}
 		}
@@ -84,38 +292,312 @@ func (l *FileLogger) updateLatestLog() {
 		} else {
 			l.fileSize = int64(0)
 		}
-		if l.fileSize >= l.maxSize || latestFile == nil {
+		if l.full() || latestFile == nil {
 			l.nextLogFile()
-			l.openFile(true)
+			err = l.openFile(true)
 		} else {
-			l.openFile(false)
+			err = l.openAppend()
 		}
+		if err != nil {
+			l.handleError(err)
+		}
+	}
+	return err
+}
+
+// tell if the ring file n is more recent than the ring file latest. Files
+// written within the resolution of the file system times are told apart by
+// the current file not being full yet, then by their order in the ring
+func (l *FileLogger) newerRingFile(fileInfo os.FileInfo, n int, latest os.FileInfo, latestNum int) bool {
+	if !fileInfo.ModTime().Equal(latest.ModTime()) {
+		return fileInfo.ModTime().After(latest.ModTime())
 	}
+	full := l.maxSize > 0 && fileInfo.Size() >= l.maxSize
+	latestFull := l.maxSize > 0 && latest.Size() >= l.maxSize
+	if full != latestFull {
+		return !full
+	}
+	return n == (latestNum+1)%l.ringSize()
+}
+
+// return the rotate index of a file found in the log directory, the file
+// names are matched without the directory part of the logger name and may
+// have the suffix of a compressed file. The ring counts from 0 and
+// RotateShift from 1. The rest of the name must be the index exactly as
+// getLogFileName writes it, so decoys like name.old, name.+1 or name.01 are
+// not taken for log files, nor are directories
+func (l *FileLogger) rotateIndex(fileInfo os.FileInfo) (int, bool) {
+	fileName := fileInfo.Name()
+	prefix := filepath.Base(l.name) + "."
+	if fileInfo.IsDir() || !strings.HasPrefix(fileName, prefix) {
+		return 0, false
+	}
+	first := 0
+	if l.strategy == RotateShift {
+		first = 1
+	}
+	suffix := trimCompressed(fileName[len(prefix):])
+	n, err := strconv.Atoi(suffix)
+	if err != nil || strconv.Itoa(n) != suffix || n < first || n >= l.ringSize()+first {
+		return 0, false
+	}
+	return n, true
 }
 
 // open the file and truncate the file if trunc is true
 func (l *FileLogger) openFile(trunc bool) error {
+	mode := l.fileMode
+	preserved := false
 	if l.file != nil {
+		if l.preserveMode {
+			if fileInfo, err := l.file.Stat(); err == nil {
+				mode = fileInfo.Mode().Perm()
+				preserved = true
+			}
+		}
+		l.flush()
 		l.file.Close()
 	}
+	l.unmap()
 	var err error
-	fileName := l.GetCurrentLogFile()
+	fileName := l.currentLogFile()
 	if trunc {
-		l.file, err = os.Create(fileName)
+		l.file, err = l.fs.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+		//a new file starts empty, whatever the old one counted
+		if err == nil {
+			l.fileSize = 0
+		}
+		l.fileWrites = 0
+		l.fileCreated = l.clock.Now()
+		// the file may already exist with another mode, and the umask
+		// applies to the new ones
+		if err == nil && preserved {
+			err = l.file.Chmod(mode)
+		}
 	} else {
-		l.file, err = os.OpenFile(fileName, os.O_RDWR|os.O_APPEND, 0666)
+		l.file, err = l.fs.OpenFile(fileName, os.O_RDWR|os.O_APPEND, l.fileMode)
+		//the creation time is not portable, the last change is the best guess
+		if err == nil {
+			if fileInfo, e := l.file.Stat(); e == nil {
+				l.fileCreated = fileInfo.ModTime()
+			}
+		}
+	}
+	if err == nil && l.bufSize > 0 {
+		if l.buf == nil {
+			l.buf = bufio.NewWriterSize(l.file, l.bufSize)
+		} else {
+			l.buf.Reset(l.file)
+		}
+	}
+	if err == nil && trunc && l.aead != nil {
+		err = l.writeEncryptMagic()
+	}
+	if err == nil && !trunc {
+		//never mix plain and encrypted records
+		if err = l.checkEncryption(); err != nil {
+			l.file.Close()
+			l.file = nil
+		}
+	}
+	if err == nil && trunc {
+		l.writeHeader()
+	}
+	if err == nil && l.symlink && l.strategy != RotateShift {
+		if e := l.updateSymlink(); e != nil {
+			l.handleError(e)
+		}
 	}
 	return err
 }
 
+// open the current log file in append mode, creating it if it is missing,
+// and take the size from the file
+func (l *FileLogger) openCurrent() error {
+	err := l.openAppend()
+	if os.IsNotExist(err) {
+		err = l.openFile(true)
+	}
+	if err != nil {
+		return err
+	}
+	fileInfo, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	l.fileSize = fileInfo.Size()
+	return nil
+}
+
+// open the current log file in append mode. A plain file found by a logger
+// with a key is rotated out, so an encrypted file is started; a ring of a
+// single file truncates it
+func (l *FileLogger) openAppend() error {
+	err := l.openFile(false)
+	if err == errNotEncrypted {
+		l.handleError(err)
+		err = l.doRotate()
+	}
+	return err
+}
+
+// reopen the current log file if it was removed, replaced or truncated
+// since it was opened, the caller must hold the lock
+func (l *FileLogger) checkExternalChanges() error {
+	own, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	//the buffered bytes are counted but not on disk yet
+	size := l.fileSize
+	if l.buf != nil {
+		size -= int64(l.buf.Buffered())
+	}
+	cur, err := l.fs.Stat(l.currentLogFile())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && os.SameFile(cur, own) && cur.Size() >= size {
+		return nil
+	}
+	return l.openCurrent()
+}
+
+// return the writer of the current log file, buffered if buffering is enabled
+func (l *FileLogger) writer() io.Writer {
+	var w io.Writer = l.file
+	if l.buf != nil {
+		w = l.buf
+	}
+	if l.aead != nil {
+		return encryptWriter{w: w, aead: l.aead}
+	}
+	return w
+}
+
+// write the buffered data to the current log file
+func (l *FileLogger) flush() {
+	if l.buf != nil {
+		if err := l.buf.Flush(); err != nil {
+			l.handleError(err)
+		}
+	}
+}
+
+// start flushing the buffer every flushInterval
+func (l *FileLogger) startFlushLoop() {
+	l.flushStop = make(chan struct{})
+	l.flushDone = make(chan struct{})
+	go l.flushLoop(l.flushStop, l.flushDone)
+}
+
+// flush the buffer on every tick until stop is closed
+func (l *FileLogger) flushLoop(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	tick, stopTicker := l.newTicker(l.flushInterval)
+	defer stopTicker()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick:
+			l.locker.Lock()
+			if !l.closed && l.file != nil {
+				l.flush()
+			}
+			l.locker.Unlock()
+		}
+	}
+}
+
+// write the footer to the current log file before it is closed
+func (l *FileLogger) writeFooter() {
+	if l.footer == nil || l.file == nil {
+		return
+	}
+	n, _ := l.writer().Write(l.footer())
+	l.fileSize += int64(n)
+}
+
+// write the header at the start of a new log file
+func (l *FileLogger) writeHeader() {
+	if l.header == nil {
+		return
+	}
+	n, _ := l.writer().Write(l.header())
+	l.fileSize += int64(n)
+}
+
+// Name returns the name the log files are derived from
+func (l *FileLogger) Name() string {
+	return l.name
+}
+
 // get the name of current log file
 func (l *FileLogger) GetCurrentLogFile() string {
-	return l.getLogFileName(l.curRotate)
+	l.rlock()
+	defer l.runlock()
+
+	return l.currentLogFile()
 }
 
 // get the name of previous log file
 func (l *FileLogger) GetPrevLogFile() string {
-	i := (l.curRotate - 1 + l.backups) % l.backups
+	l.rlock()
+	defer l.runlock()
+
+	return l.prevLogFile()
+}
+
+// GetNextLogFile returns the name of the file the next rotation will write
+// to, without rotating. It is the current file itself when the logger never
+// rotates or with RotateShift, and for RotateTimestamp the name a rotation
+// would take now
+func (l *FileLogger) GetNextLogFile() string {
+	l.rlock()
+	defer l.runlock()
+
+	if l.backups == 0 || l.strategy == RotateShift {
+		return l.currentLogFile()
+	}
+	if l.strategy == RotateTimestamp {
+		return l.timestampLogFile(l.nextStamp())
+	}
+	return l.getLogFileName(l.nextRotate())
+}
+
+// CurrentRotation returns the ring index of the current file, and -1 for
+// the strategies whose files have no index
+func (l *FileLogger) CurrentRotation() int {
+	l.rlock()
+	defer l.runlock()
+
+	if l.strategy != RotateRing {
+		return -1
+	}
+	return l.curRotate
+}
+
+// the lock free part of GetCurrentLogFile
+func (l *FileLogger) currentLogFile() string {
+	if l.strategy == RotateTimestamp {
+		return l.curFile
+	}
+	if l.strategy == RotateShift {
+		return l.name
+	}
+	return l.getLogFileName(l.curRotate)
+}
+
+// the lock free part of GetPrevLogFile
+func (l *FileLogger) prevLogFile() string {
+	if l.strategy == RotateTimestamp {
+		return l.prevTimestampLogFile()
+	}
+	if l.strategy == RotateShift {
+		return l.getLogFileName(1)
+	}
+	i := (l.curRotate - 1 + l.ringSize()) % l.ringSize()
 
 	return l.getLogFileName(i)
 }
@@ -132,108 +614,282 @@ func (l *FileLogger) ClearCurLogFile() error {
 	return l.openFile(true)
 }
 
+// Reopen closes the current log file and opens it again by name, in append
+// mode and creating it if it is missing, taking its size from the new file.
+// Call it once an external tool like logrotate moved the file away, from a
+// SIGHUP handler for example
+func (l *FileLogger) Reopen() error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if l.closed {
+		return ErrLoggerClosed
+	}
+	if err := l.openCurrent(); err != nil {
+		l.handleError(err)
+		return err
+	}
+	return nil
+}
+
 func (l *FileLogger) ClearAllLogFile() error {
 	l.locker.Lock()
 	defer l.locker.Unlock()
 
-	for i := 0; i < l.backups; i++ {
-		logFile := l.getLogFileName(i)
-		err := os.Remove(logFile)
+	if l.strategy != RotateRing {
+		files, err := l.listLogFiles()
 		if err != nil {
-			return NewFault(FAILED, "FAILED")
+			return NewFaultWrap(FAILED, "FAILED", err)
+		}
+		for _, f := range files {
+			l.discardCompressed(trimCompressed(f.name))
+			if err = l.fs.Remove(f.name); err != nil && !os.IsNotExist(err) {
+				return NewFaultWrap(FAILED, "FAILED", err)
+			}
+		}
+		l.nextLogFile()
+		if err = l.openFile(true); err != nil {
+			return NewFaultWrap(FAILED, "FAILED", err)
+		}
+		return nil
+	}
+	for i := 0; i < l.ringSize(); i++ {
+		logFile := l.getLogFileName(i)
+		l.discardCompressed(logFile)
+		err := l.fs.Remove(logFile)
+		if err != nil && !os.IsNotExist(err) {
+			return NewFaultWrap(FAILED, "FAILED", err)
 		}
 	}
 	l.curRotate = 0
 	err := l.openFile(true)
 	if err != nil {
-		return NewFault(FAILED, "FAILED")
+		return NewFaultWrap(FAILED, "FAILED", err)
 	}
 	return nil
 }
 
+// ReadLog reads the current log file, a compressed one decompressed. The
+// offset and length select the bytes:
+//
+//   - offset >= 0 and length == 0 reads from offset to the end of file
+//   - offset >= 0 and length > 0 reads length bytes from offset
+//   - offset < 0 and length == 0 reads the last -offset bytes
+//   - offset < 0 and length > 0 reads length bytes from -offset bytes before
+//     the end of file
+//
+// A negative offset further back than the start of file starts at 0, a read
+// stops at the end of file, and an offset past the end of file returns an
+// empty string. A negative length is BAD_ARGUMENTS
 func (l *FileLogger) ReadLog(offset int64, length int64) (string, error) {
-	if offset < 0 && length != 0 {
-		return "", NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	data, _, _, err := l.ReadLogPage(offset, length)
+	return data, err
+}
+
+// ReadLogPage reads the current log file like ReadLog and also returns the
+// offset following the data read and whether it reached the end of file, so
+// a client can page through the file until eof. A read past the end of file
+// returns its offset back with eof set
+func (l *FileLogger) ReadLogPage(offset int64, length int64) (string, int64, bool, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", offset, false, err
 	}
-	if offset >= 0 && length < 0 {
-		return "", NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	l.rlock()
+	defer l.runlock()
+
+	f, fileLen, err := l.openCurrentRange()
+	if err != nil {
+		return "", offset, false, NewFaultWrap(FAILED, "FAILED", err)
 	}
+	defer f.Close()
 
-	l.locker.Lock()
-	defer l.locker.Unlock()
-	f, err := os.Open(l.GetCurrentLogFile())
+	return ReadAtPage(f, fileLen, offset, length)
+}
+
+// ReadLogBytes reads the current log file like ReadLog but returns the read
+// buffer itself, saving the copy to a string. The returned slice belongs to
+// the caller
+func (l *FileLogger) ReadLogBytes(offset int64, length int64) ([]byte, error) {
+	l.rlock()
+	defer l.runlock()
+
+	return readFileRange(l.fs, l.currentLogFile(), l.aead, offset, length)
+}
+
+// ReadFile reads length bytes of any file from offset with the same rules
+// as ReadLog: a negative offset counts from the end of file, a zero length
+// reads up to the end of file, and a read past the end of file returns an
+// empty string
+func ReadFile(path string, offset int64, length int64) (string, error) {
+	return readFileString(osFS{}, path, nil, offset, length)
+}
 
+// read length bytes of a file from offset with the ReadLog rules
+func readFileRange(fs FS, fileName string, aead cipher.AEAD, offset int64, length int64) ([]byte, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return nil, err
+	}
+
+	//a compressed file is read decompressed
+	f, fileLen, err := openRange(fs, fileName, aead)
 	if err != nil {
-		return "", NewFault(FAILED, "FAILED")
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
 	}
 	defer f.Close()
 
-	//check the length of file
-	statInfo, err := f.Stat()
+	return ReadAtRange(f, fileLen, offset, length)
+}
+
+// readFileRange returning a string, read through a pooled buffer
+func readFileString(fs FS, fileName string, aead cipher.AEAD, offset int64, length int64) (string, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", err
+	}
+
+	f, fileLen, err := openRange(fs, fileName, aead)
 	if err != nil {
-		return "", NewFault(FAILED, "FAILED")
+		return "", NewFaultWrap(FAILED, "FAILED", err)
 	}
+	defer f.Close()
 
-	fileLen := statInfo.Size()
+	return readAtRangeString(f, fileLen, offset, length)
+}
 
-	if offset < 0 { //offset < 0 && length == 0
+// check the offset and length given to ReadLog
+func checkReadArgs(offset int64, length int64) error {
+	if length < 0 {
+		return NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	return nil
+}
+
+// ReadAtRange reads length bytes from offset of r, whose size is fileLen,
+// with the ReadLog rules. It lets a Logger not backed by a local file
+// behave exactly like FileLogger
+func ReadAtRange(r io.ReaderAt, fileLen int64, offset int64, length int64) ([]byte, error) {
+	return readAtRange(r, fileLen, offset, length, func(n int) []byte {
+		return make([]byte, n)
+	})
+}
+
+// ReadAtPage reads r, whose size is fileLen, with the ReadLogPage rules
+func ReadAtPage(r io.ReaderAt, fileLen int64, offset int64, length int64) (string, int64, bool, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", offset, false, err
+	}
+	start, _ := readBounds(fileLen, offset, length)
+	data, err := readAtRangeString(r, fileLen, offset, length)
+	if err != nil {
+		return "", offset, false, err
+	}
+	next := start + int64(len(data))
+	return data, next, next >= fileLen, nil
+}
+
+// ReadAtRange returning a string, read through a pooled buffer
+func readAtRangeString(r io.ReaderAt, fileLen int64, offset int64, length int64) (string, error) {
+	var buf []byte
+	b, err := readAtRange(r, fileLen, offset, length, func(n int) []byte {
+		buf = getReadBuf(n)
+		return buf
+	})
+	s := string(b)
+	if buf != nil {
+		putReadBuf(buf)
+	}
+	return s, err
+}
+
+// ReadAtRange reading into a buffer of the given size made by alloc
+func readAtRange(r io.ReaderAt, fileLen int64, offset int64, length int64, alloc func(n int) []byte) ([]byte, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return nil, err
+	}
+
+	offset, length = readBounds(fileLen, offset, length)
+	if length == 0 {
+		return nil, nil
+	}
+	b := alloc(int(length))
+	n, err := r.ReadAt(b, offset)
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	return b[:n], nil
+}
+
+// return where a read with the ReadLog rules starts in a file of fileLen
+// bytes and how many bytes it reads, the arguments must be checked
+func readBounds(fileLen int64, offset int64, length int64) (int64, int64) {
+	//a negative offset counts from the end of file
+	if offset < 0 {
 		offset = fileLen + offset
 		if offset < 0 {
 			offset = 0
 		}
-		length = fileLen - offset
-	} else if length == 0 { //offset >= 0 && length == 0
-		if offset > fileLen {
-			return "", nil
-		}
-		length = fileLen - offset
-	} else { //offset >= 0 && length > 0
-
-		//if the offset exceeds the length of file
-		if offset >= fileLen {
-			return "", nil
-		}
-
-		//compute actual bytes should be read
+	}
 
-		if offset+length > fileLen {
-			length = fileLen - offset
-		}
+	//if the offset exceeds the length of file
+	if offset >= fileLen {
+		return offset, 0
 	}
 
-	b := make([]byte, length)
-	n, err := f.ReadAt(b, offset)
-	if err != nil {
-		return "", NewFault(FAILED, "FAILED")
+	//compute actual bytes should be read
+	if length == 0 || offset+length > fileLen {
+		length = fileLen - offset
 	}
-	return string(b[:n]), nil
+	return offset, length
 }
 
-func (l *FileLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+// check the offset and length given to ReadTailLog
+func checkTailArgs(offset int64, length int64) error {
 	if offset < 0 {
-		return "", offset, false, fmt.Errorf("offset should not be less than 0")
+		return fmt.Errorf("offset should not be less than 0")
 	}
 	if length < 0 {
-		return "", offset, false, fmt.Errorf("length should be not be less than 0")
+		return fmt.Errorf("length should be not be less than 0")
 	}
-	l.locker.Lock()
-	defer l.locker.Unlock()
+	return nil
+}
 
-	//open the file
-	f, err := os.Open(l.GetCurrentLogFile())
+// ReadTailLog reads the current log file from offset, see ReadTailAt. A
+// compressed file is decompressed in memory first, so offset and length
+// count the decompressed bytes, but tailing it costs its whole size on
+// every call instead of the bytes read
+func (l *FileLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	if err := checkTailArgs(offset, length); err != nil {
+		return "", offset, false, err
+	}
+	l.rlock()
+	defer l.runlock()
+
+	//open the file, a compressed file is read decompressed
+	f, fileLen, err := l.openCurrentRange()
 	if err != nil {
 		return "", 0, false, err
 	}
 
 	defer f.Close()
 
-	//get the length of file
-	statInfo, err := f.Stat()
-	if err != nil {
-		return "", 0, false, err
+	return ReadTailAt(f, fileLen, offset, length)
+}
+
+// ReadTailAt reads r, whose size is fileLen, with the ReadTailLog rules.
+//
+// An offset past the end of file means the file was truncated in place
+// since the client got it, like logrotate copytruncate does. The read then
+// starts again from the beginning of the file and the overflow flag is set,
+// telling the client to resync
+func ReadTailAt(r io.ReaderAt, fileLen int64, offset int64, length int64) (string, int64, bool, error) {
+	if err := checkTailArgs(offset, length); err != nil {
+		return "", offset, false, err
 	}
 
-	fileLen := statInfo.Size()
+	truncated := false
+	if offset > fileLen {
+		offset = 0
+		truncated = true
+	}
 
 	//check if offset exceeds the length of file
 	if offset >= fileLen {
@@ -246,46 +902,317 @@ func (l *FileLogger) ReadTailLog(offset int64, length int64) (string, int64, boo
 		length = fileLen - offset
 	}
 
-	b := make([]byte, length)
-	n, err := f.ReadAt(b, offset)
+	b := getReadBuf(int(length))
+	defer putReadBuf(b)
+	n, err := r.ReadAt(b, offset)
 	if err != nil {
 		return "", offset, false, err
 	}
-	return string(b[:n]), offset + int64(n), false, nil
+	return string(b[:n]), offset + int64(n), truncated, nil
 
 }
 
 // Override the function in io.Writer
 func (l *FileLogger) Write(p []byte) (int, error) {
+	defer l.notifyRotated()
 	l.locker.Lock()
 	defer l.locker.Unlock()
 
-	n, err := l.file.Write(p)
-
+	l.records.Add(1)
+	b, err := l.transform(p)
 	if err != nil {
-		return n, err
+		return 0, err
 	}
-	l.fileSize += int64(n)
-	if l.fileSize >= l.maxSize {
-		fileInfo, err := os.Stat(fmt.Sprintf("%s.%d", l.name, l.curRotate))
-		if err == nil {
-			l.fileSize = fileInfo.Size()
-		} else {
-			return n, err
+	n, err := l.write(append(l.prefix(), b)...)
+	return l.written(n, err, len(p))
+}
+
+// WriteMulti writes the buffers one after the other as a single record,
+// under one lock and with one rotation check, so they always end up together
+// in the same file. It saves joining a prefix and a message into a new
+// buffer. os.File has no writev, so the buffers are written in turn, into
+// the write buffer if it is enabled
+func (l *FileLogger) WriteMulti(bufs ...[]byte) (int, error) {
+	defer l.notifyRotated()
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	l.records.Add(1)
+	length := 0
+	out := l.prefix()
+	for _, p := range bufs {
+		b, err := l.transform(p)
+		if err != nil {
+			return 0, err
 		}
+		out = append(out, b)
+		length += len(p)
 	}
-	if l.fileSize >= l.maxSize {
-		l.nextLogFile()
-		l.openFile(true)
+	n, err := l.write(out...)
+	return l.written(n, err, length)
+}
+
+// WriteString writes s like Write, implementing io.StringWriter. The bytes
+// of s are written as they are, without the copy of a []byte(s) conversion
+func (l *FileLogger) WriteString(s string) (int, error) {
+	return l.Write(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// WriteRune writes the UTF-8 encoding of r with a single locked write, so a
+// multi-byte rune is never split across a rotation. It has the signature of
+// bufio.Writer.WriteRune for code that builds its output rune by rune
+func (l *FileLogger) WriteRune(r rune) (int, error) {
+	var b [utf8.UTFMax]byte
+	return l.Write(b[:utf8.EncodeRune(b[:], r)])
+}
+
+// WriteContext writes p like Write but returns ctx.Err() once ctx is done,
+// nothing is written if it is done already. A blocked write can't be
+// aborted, so the write may still complete in the background after
+// WriteContext returned, p is copied for it
+func (l *FileLogger) WriteContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	type result struct {
+		n   int
+		err error
+	}
+	b := append([]byte(nil), p...)
+	done := make(chan result, 1)
+	go func() {
+		n, err := l.Write(b)
+		done <- result{n, err}
+	}()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// the size of the chunks ReadFrom reads
+const readFromChunkSize = 32 << 10
+
+// ReadFrom writes what r gives until EOF, implementing io.ReaderFrom so
+// io.Copy uses it. Every chunk read is written like a Write, under one
+// lock acquisition, so a copy rotates the file as soon as it is full
+func (l *FileLogger) ReadFrom(r io.Reader) (int64, error) {
+	buf := getReadBuf(readFromChunkSize)
+	defer putReadBuf(buf)
+
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			written, werr := l.Write(buf[:n])
+			total += int64(written)
+			if werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// apply the UTF-8 check and the line limit to p, p itself is returned if it
+// is left unchanged
+func (l *FileLogger) transform(p []byte) ([]byte, error) {
+	b := p
+	if l.stripANSI != nil {
+		b = l.stripANSI.strip(b)
+	}
+	if l.validateUTF8 && !utf8.Valid(b) {
+		if !l.replaceUTF8 {
+			return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+		}
+		b = bytes.ToValidUTF8(b, []byte(string(utf8.RuneError)))
+	}
+	if l.lineLimit != nil {
+		b = l.lineLimit.limit(b)
+	}
+	return b, nil
+}
+
+// remember the error of a write and turn the number of transformed bytes
+// written into the length of the caller's data, as io.Writer requires
+func (l *FileLogger) written(n int, err error, length int) (int, error) {
+	if err != nil {
+		l.lastErr.Store(&err)
+	}
+	if err == nil || n > length {
+		n = length
 	}
 	return n, err
 }
 
+// return what is written before each record: the timestamp, then the labels
+func (l *FileLogger) prefix() [][]byte {
+	out := make([][]byte, 0, 3)
+	if l.timestampPrefix {
+		layout := l.timestampLayout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		out = append(out, []byte(l.clock.Now().Format(layout)+" "))
+	}
+	if l.labelPrefix != nil {
+		out = append(out, l.labelPrefix)
+	}
+	return out
+}
+
+// write the buffers to the current log file and rotate it if needed, the
+// caller must hold the lock
+func (l *FileLogger) write(bufs ...[]byte) (int, error) {
+	l.lastErr.Store(nil)
+	if l.file == nil {
+		//try once to recover from a failed open or rotation
+		if err := l.openCurrent(); err != nil {
+			return 0, l.invariant(fmt.Errorf("%w: %w", ErrFileNotOpen, err))
+		}
+	}
+	if l.watchExternal {
+		if err := l.checkExternalChanges(); err != nil {
+			l.handleError(err)
+			if l.file == nil {
+				return 0, err
+			}
+		}
+	}
+	//an expired file is rotated before the write so the record starts the new file
+	if l.expired() {
+		if err := l.doRotate(); err != nil {
+			l.handleError(err)
+			if l.file == nil {
+				return 0, err
+			}
+		}
+	}
+	total := 0
+	newline := false
+	for _, p := range bufs {
+		n, err := l.writer().Write(p)
+		total += n
+		l.fileSize += int64(n)
+		l.bytesWritten += int64(n)
+		if err != nil {
+			return total, err
+		}
+		newline = newline || bytes.IndexByte(p, '\n') >= 0
+	}
+	l.fileWrites++
+	if l.buf != nil && l.flushOnNewline && newline {
+		l.flush()
+	}
+	if l.syncOnWrite {
+		if err := l.sync(); err != nil {
+			return total, err
+		}
+	}
+	if l.shouldRotate() {
+		if err := l.doRotate(); err != nil {
+			l.handleError(err)
+		}
+	}
+	return total, nil
+}
+
+// give an error the caller can't see to the error handler
+func (l *FileLogger) handleError(err error) {
+	l.lastErr.Store(&err)
+	if l.errorHandler != nil {
+		l.errorHandler(err)
+	}
+}
+
+// report the violation of an invariant: panic if the logger is set to fail
+// fast, or else give it to the error handler and return it
+func (l *FileLogger) invariant(err error) error {
+	if l.panicOnError {
+		panic(err)
+	}
+	l.handleError(err)
+	return err
+}
+
+// RecordCount returns the number of Write calls since the logger was
+// created, which is the line count for a line-delimited log
+func (l *FileLogger) RecordCount() int64 {
+	return l.records.Load()
+}
+
+// LastError returns the error of the last write or rotation, or nil if it
+// succeeded. It is a best effort snapshot of the current health of the
+// logger, not a record of all the errors
+func (l *FileLogger) LastError() error {
+	if err := l.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Sync writes the buffered data and commits the current log file to stable
+// storage with fsync, so the records written before survive a crash of the
+// system. It is slow, from milliseconds to tens of milliseconds per call
+// depending on the disk
+func (l *FileLogger) Sync() error {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if l.file == nil {
+		return ErrFileNotOpen
+	}
+	return l.sync()
+}
+
+// the lock free part of Sync
+func (l *FileLogger) sync() error {
+	if l.buf != nil {
+		if err := l.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	return l.file.Sync()
+}
+
+// Close waits for the compressions and post-rotate commands in progress to
+// finish, then closes the current log file
 func (l *FileLogger) Close() error {
+	l.locker.Lock()
+	stop, done := l.flushStop, l.flushDone
+	l.flushStop = nil
+	l.locker.Unlock()
+	//stop the flush loop first, it takes the lock
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	//a failed post-rotate command still writes its output to the file
+	l.compressWG.Wait()
+
+	l.locker.Lock()
+	var err error
+	if !l.closed && l.rotationEvents != nil {
+		close(l.rotationEvents)
+	}
+	l.closed = true
 	if l.file != nil {
-		return l.file.Close()
+		l.writeFooter()
+		l.flush()
+		err = l.file.Close()
 	}
-	return nil
+	l.unmap()
+	l.locker.Unlock()
+
+	l.compressWG.Wait()
+	return err
 }
 
 func NewNullLogger() *NullLogger {
@@ -296,6 +1223,18 @@ func (l *NullLogger) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+func (l *NullLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *NullLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *NullLogger) WriteString(s string) (int, error) {
+	return len(s), nil
+}
+
 func (l *NullLogger) Close() error {
 	return nil
 }
@@ -308,6 +1247,22 @@ func (l *NullLogger) ReadTailLog(offset int64, length int64) (string, int64, boo
 	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
 }
 
+func (l *NullLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, nil
+}
+
+func (l *NullLogger) LineCount() (int64, error) {
+	return 0, nil
+}
+
+func (l *NullLogger) Stats() LoggerStats {
+	return LoggerStats{}
+}
+
+func (l *NullLogger) Sync() error {
+	return nil
+}
+
 func (l *NullLogger) ClearCurLogFile() error {
 	return fmt.Errorf("No log")
 }
@@ -316,6 +1271,12 @@ func (l *NullLogger) ClearAllLogFile() error {
 	return NewFault(NO_FILE, "NO_FILE")
 }
 
+func (l *NullLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
+
+// NewNullLocker returns a locker that locks nothing. A logger locked by it
+// must only be used by one goroutine at a time, or its state is corrupted
 func NewNullLocker() *NullLocker {
 	return &NullLocker{}
 }
@@ -326,6 +1287,32 @@ func (l *NullLocker) Lock() {
 func (l *NullLocker) Unlock() {
 }
 
+// a locker whose reads can run together
+type rwLocker interface {
+	sync.Locker
+	RLock()
+	RUnlock()
+}
+
+// take locker for a read which changes no state of the logger, shared with
+// the other reads when locker allows it
+func (l *FileLogger) rlock() {
+	if rw, ok := l.locker.(rwLocker); ok {
+		rw.RLock()
+		return
+	}
+	l.locker.Lock()
+}
+
+// release locker taken by rlock
+func (l *FileLogger) runlock() {
+	if rw, ok := l.locker.(rwLocker); ok {
+		rw.RUnlock()
+		return
+	}
+	l.locker.Unlock()
+}
+
 type StdoutLogger struct {
 }
 
@@ -337,6 +1324,18 @@ func (l *StdoutLogger) Write(p []byte) (int, error) {
 	return os.Stdout.Write(p)
 }
 
+func (l *StdoutLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *StdoutLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *StdoutLogger) WriteString(s string) (int, error) {
+	return os.Stdout.WriteString(s)
+}
+
 func (l *StdoutLogger) Close() error {
 	return nil
 }
@@ -349,6 +1348,22 @@ func (l *StdoutLogger) ReadTailLog(offset int64, length int64) (string, int64, b
 	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
 }
 
+func (l *StdoutLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, nil
+}
+
+func (l *StdoutLogger) LineCount() (int64, error) {
+	return 0, nil
+}
+
+func (l *StdoutLogger) Stats() LoggerStats {
+	return LoggerStats{}
+}
+
+func (l *StdoutLogger) Sync() error {
+	return nil
+}
+
 func (l *StdoutLogger) ClearCurLogFile() error {
 	return fmt.Errorf("No log")
 }
@@ -357,6 +1372,10 @@ func (l *StdoutLogger) ClearAllLogFile() error {
 	return NewFault(NO_FILE, "NO_FILE")
 }
 
+func (l *StdoutLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
+
 type StderrLogger struct {
 }
 
@@ -368,6 +1387,18 @@ func (l *StderrLogger) Write(p []byte) (int, error) {
 	return os.Stderr.Write(p)
 }
 
+func (l *StderrLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *StderrLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *StderrLogger) WriteString(s string) (int, error) {
+	return os.Stderr.WriteString(s)
+}
+
 func (l *StderrLogger) Close() error {
 	return nil
 }
@@ -380,6 +1411,22 @@ func (l *StderrLogger) ReadTailLog(offset int64, length int64) (string, int64, b
 	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
 }
 
+func (l *StderrLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, nil
+}
+
+func (l *StderrLogger) LineCount() (int64, error) {
+	return 0, nil
+}
+
+func (l *StderrLogger) Stats() LoggerStats {
+	return LoggerStats{}
+}
+
+func (l *StderrLogger) Sync() error {
+	return nil
+}
+
 func (l *StderrLogger) ClearCurLogFile() error {
 	return fmt.Errorf("No log")
 }
@@ -387,3 +1434,7 @@ func (l *StderrLogger) ClearCurLogFile() error {
 func (l *StderrLogger) ClearAllLogFile() error {
 	return NewFault(NO_FILE, "NO_FILE")
 }
+
+func (l *StderrLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// create a FileLogger in a temporary directory, closed at the end of the test
+func newTestLogger(t testing.TB, maxSize int64, backups int, opts ...Option) *FileLogger {
+	t.Helper()
+	l, err := NewFileLoggerE(filepath.Join(t.TempDir(), "test.log"), maxSize, backups, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// write n numbered lines of size bytes, newline included
+func writeTestLines(t testing.TB, l Logger, n int, size int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("%0*d\n", size-1, i)
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "any.txt")
+	if err := os.WriteFile(name, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !filepath.IsAbs(name) {
+		t.Fatalf("%s is not an absolute path", name)
+	}
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 0, "0123456789"},
+		{2, 3, "234"},
+		{-4, 0, "6789"},
+		{-4, 2, "67"},
+		{8, 10, "89"},
+		{20, 0, ""},
+		{-20, 3, "012"},
+	}
+	for _, tt := range tests {
+		got, err := ReadFile(name, tt.offset, tt.length)
+		if err != nil || got != tt.want {
+			t.Errorf("ReadFile(%d, %d) = %q, %v, want %q", tt.offset, tt.length, got, err, tt.want)
+		}
+	}
+
+	if _, err := ReadFile(name, 0, -1); faultCode(err) != BAD_ARGUMENTS {
+		t.Errorf("negative length: %v, want BAD_ARGUMENTS", err)
+	}
+	if _, err := ReadFile(name+".missing", 0, 0); err == nil {
+		t.Error("no error reading a missing file")
+	}
+}
+
+// return the code of the fault err, or 0 if it is not one
+func faultCode(err error) int {
+	var fault *Fault
+	if errors.As(err, &fault) {
+		return fault.Code
+	}
+	return 0
+}
+
+func TestWriteAfterDirectoryRemoved(t *testing.T) {
+	for _, panicOnError := range []bool{false, true} {
+		dir := filepath.Join(t.TempDir(), "logs")
+		var handled []error
+		l, err := NewFileLoggerE(filepath.Join(dir, "test.log"), 10, 3, nil,
+			WithPanicOnError(panicOnError),
+			WithErrorHandler(func(err error) { handled = append(handled, err) }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			l.Close()
+			t.Skipf("can't remove the directory of an open file: %v", err)
+		}
+		//the write goes to the removed file, then the rotation fails
+		if _, err := l.Write([]byte("123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+		if len(handled) == 0 || l.file != nil {
+			t.Fatalf("panic %v: rotation errors %v, file %v, want a failed rotation", panicOnError, handled, l.file)
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); (r != nil) != panicOnError {
+					t.Errorf("panic %v: recovered %v", panicOnError, r)
+				}
+			}()
+			if _, err := l.Write([]byte("lost\n")); !errors.Is(err, ErrFileNotOpen) {
+				t.Errorf("panic %v: Write = %v, want ErrFileNotOpen", panicOnError, err)
+			}
+		}()
+
+		//the next write reopens the file once the directory is back
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Write([]byte("back\n")); err != nil {
+			t.Errorf("panic %v: Write after the directory is back: %v", panicOnError, err)
+		}
+		if got, _ := l.ReadLog(0, 0); got != "back\n" {
+			t.Errorf("panic %v: ReadLog = %q, want the write after the directory is back", panicOnError, got)
+		}
+		l.Close()
+	}
+}
+
+func TestReadLogPages(t *testing.T) {
+	l := newTestLogger(t, 0, 3)
+	content := "line one\nline two\nline three\n"
+	if _, err := l.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	offset := int64(0)
+	for pages := 0; ; pages++ {
+		if pages > len(content) {
+			t.Fatal("the pages never reach the end of file")
+		}
+		data, next, eof, err := l.ReadLogPage(offset, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next != offset+int64(len(data)) {
+			t.Fatalf("page at %d: next %d after %d bytes", offset, next, len(data))
+		}
+		got += data
+		offset = next
+		if eof {
+			break
+		}
+	}
+	if got != content || offset != int64(len(content)) {
+		t.Errorf("pages %q ending at %d, want the whole file", got, offset)
+	}
+
+	//the next writes are read from where the last page ended
+	if _, err := l.Write([]byte("line four\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, next, eof, _ := l.ReadLogPage(offset, 0); data != "line four\n" || next != offset+10 || !eof {
+		t.Errorf("ReadLogPage = %q, %d, %v after a write", data, next, eof)
+	}
+	//a negative offset gives the position of the data read
+	if data, next, eof, _ := l.ReadLogPage(-5, 2); data != "fo" || next != offset+7 || eof {
+		t.Errorf("ReadLogPage(-5, 2) = %q, %d, %v", data, next, eof)
+	}
+	//past the end of file the offset comes back
+	if data, next, eof, _ := l.ReadLogPage(100, 4); data != "" || next != 100 || !eof {
+		t.Errorf("ReadLogPage(100, 4) = %q, %d, %v", data, next, eof)
+	}
+	if _, _, _, err := l.ReadLogPage(0, -1); faultCode(err) != BAD_ARGUMENTS {
+		t.Errorf("negative length: %v, want BAD_ARGUMENTS", err)
+	}
+}
+
+func TestReadLogOffsetLengthMatrix(t *testing.T) {
+	l := newTestLogger(t, 0, 3)
+	content := "0123456789"
+	if _, err := l.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	size := len(content)
+	for _, offset := range []int64{-20, -11, -10, -9, -5, -1, 0, 1, 5, 9, 10, 11, 20} {
+		for _, length := range []int64{0, 1, 3, 9, 10, 11, 20} {
+			//the ReadLog rules told as a slice of the content
+			start := int(offset)
+			if start < 0 {
+				start += size
+				if start < 0 {
+					start = 0
+				}
+			}
+			want := ""
+			if start < size {
+				end := size
+				if length > 0 && start+int(length) < size {
+					end = start + int(length)
+				}
+				want = content[start:end]
+			}
+
+			got, err := l.ReadLog(offset, length)
+			if err != nil || got != want {
+				t.Errorf("ReadLog(%d, %d) = %q, %v, want %q", offset, length, got, err, want)
+			}
+			b, err := ReadAtRange(bytes.NewReader([]byte(content)), int64(size), offset, length)
+			if err != nil || string(b) != want {
+				t.Errorf("ReadAtRange(%d, %d) = %q, %v, want %q", offset, length, b, err, want)
+			}
+		}
+	}
+	for _, offset := range []int64{-5, 0, 5} {
+		if _, err := l.ReadLog(offset, -1); faultCode(err) != BAD_ARGUMENTS {
+			t.Errorf("ReadLog(%d, -1) = %v, want BAD_ARGUMENTS", offset, err)
+		}
+	}
+}
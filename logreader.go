@@ -0,0 +1,32 @@
+package core
+
+import (
+	"io"
+)
+
+// sectionReadCloser reads a section of a file and closes the file
+type sectionReadCloser struct {
+	*io.SectionReader
+	io.Closer
+}
+
+// LogReader returns a reader over length bytes of the current log file from
+// offset, with the ReadLog rules, so a large window can be streamed without
+// being held in memory. The file is opened and its size taken by the call,
+// the reader then holds no lock and keeps reading the same file even after
+// a rotation. The caller must close it
+func (l *FileLogger) LogReader(offset int64, length int64) (io.ReadCloser, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return nil, err
+	}
+	l.rlock()
+	defer l.runlock()
+
+	//a compressed file is read decompressed
+	f, fileLen, err := openRange(l.fs, l.currentLogFile(), l.aead)
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	offset, length = readBounds(fileLen, offset, length)
+	return &sectionReadCloser{SectionReader: io.NewSectionReader(f, offset, length), Closer: f}, nil
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MemoryLogger keeps everything written to it in memory, for the tests of
+// code taking a Logger. It is read like a FileLogger reads its current
+// file, with the same offset rules, and never rotates: both clears empty
+// it and there is no backup. Made by NewRingMemoryLogger it only keeps the
+// newest records
+type MemoryLogger struct {
+	lock    sync.Mutex
+	buf     []byte
+	written int64
+	closed  bool
+	// the most bytes kept, 0 keeps everything
+	capacity int
+	// the length of every record in buf, oldest first, with a capacity
+	records []int
+}
+
+func NewMemoryLogger() *MemoryLogger {
+	return &MemoryLogger{}
+}
+
+// NewRingMemoryLogger creates a MemoryLogger keeping the newest records
+// written to it up to capacity bytes, the oldest records being dropped
+// whole to make room. The last record is always kept, even when it is
+// longer than capacity
+func NewRingMemoryLogger(capacity int) *MemoryLogger {
+	return &MemoryLogger{capacity: capacity}
+}
+
+func (l *MemoryLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	l.buf = append(l.buf, p...)
+	l.written += int64(len(p))
+	if l.capacity > 0 {
+		l.records = append(l.records, len(p))
+		l.dropOldest()
+	}
+	return len(p), nil
+}
+
+// drop the oldest records until the content fits the capacity
+func (l *MemoryLogger) dropOldest() {
+	drop, n := 0, 0
+	for len(l.buf)-drop > l.capacity && n < len(l.records)-1 {
+		drop += l.records[n]
+		n++
+	}
+	if n > 0 {
+		l.buf = append(l.buf[:0], l.buf[drop:]...)
+		l.records = append(l.records[:0], l.records[n:]...)
+	}
+}
+
+func (l *MemoryLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *MemoryLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *MemoryLogger) WriteString(s string) (int, error) {
+	return l.Write([]byte(s))
+}
+
+// String returns everything written since the last clear
+func (l *MemoryLogger) String() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return string(l.buf)
+}
+
+// Close makes the next writes fail, the content can still be read
+func (l *MemoryLogger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.closed = true
+	return nil
+}
+
+func (l *MemoryLogger) ReadLog(offset int64, length int64) (string, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return readAtRangeString(bytes.NewReader(l.buf), int64(len(l.buf)), offset, length)
+}
+
+func (l *MemoryLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return ReadTailAt(bytes.NewReader(l.buf), int64(len(l.buf)), offset, length)
+}
+
+func (l *MemoryLogger) ReadTailLines(n int) ([]string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return ReadTailLinesAt(bytes.NewReader(l.buf), int64(len(l.buf)), n)
+}
+
+func (l *MemoryLogger) LineCount() (int64, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return CountLines(bytes.NewReader(l.buf))
+}
+
+// Stats returns the bytes written, CurrentFileSize is the size of the
+// content and there is no CurrentFile
+func (l *MemoryLogger) Stats() LoggerStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return LoggerStats{BytesWritten: l.written, CurrentFileSize: int64(len(l.buf))}
+}
+
+func (l *MemoryLogger) Sync() error {
+	return nil
+}
+
+func (l *MemoryLogger) ClearCurLogFile() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.buf = nil
+	l.records = nil
+	return nil
+}
+
+func (l *MemoryLogger) ClearAllLogFile() error {
+	return l.ClearCurLogFile()
+}
+
+func (l *MemoryLogger) ClearOldestBackup() (string, error) {
+	return "", ErrNoBackup
+}
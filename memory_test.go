@@ -0,0 +1,81 @@
+package core_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestMemoryLogger(t *testing.T) {
+	l := core.NewMemoryLogger()
+	for i := 0; i < 1000; i++ {
+		if _, err := l.WriteLine("record"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := l.String(); got != strings.Repeat("record\n", 1000) {
+		t.Errorf("kept %d bytes, want all the 7000 written", len(got))
+	}
+}
+
+func TestRingMemoryLogger(t *testing.T) {
+	l := core.NewRingMemoryLogger(20)
+	steps := []struct {
+		write string
+		want  string
+	}{
+		{"first\n", "first\n"},
+		{"second\n", "first\nsecond\n"},
+		{"third\n", "first\nsecond\nthird\n"},
+		//26 bytes, the oldest record makes room
+		{"fourth\n", "second\nthird\nfourth\n"},
+		{"fifth\n", "third\nfourth\nfifth\n"},
+		//a record filling the ring alone drops all the others
+		{"0123456789abcdefghi\n", "0123456789abcdefghi\n"},
+		{"x\n", "x\n"},
+		//a record over the capacity is still kept
+		{strings.Repeat("y", 30) + "\n", strings.Repeat("y", 30) + "\n"},
+		{"z\n", "z\n"},
+	}
+	written := int64(0)
+	for _, step := range steps {
+		if _, err := l.WriteString(step.write); err != nil {
+			t.Fatal(err)
+		}
+		written += int64(len(step.write))
+		if got := l.String(); got != step.want {
+			t.Errorf("after %q: kept %q, want %q", step.write, got, step.want)
+		}
+	}
+	if stats := l.Stats(); stats.BytesWritten != written || stats.CurrentFileSize != 2 {
+		t.Errorf("Stats = %+v, want %d bytes written and 2 kept", stats, written)
+	}
+
+	//the reads see only the records kept
+	l = core.NewRingMemoryLogger(20)
+	for _, s := range []string{"first", "second", "third", "fourth"} {
+		if _, err := l.WriteLine(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := l.ReadLog(0, 6); err != nil || got != "second" {
+		t.Errorf("ReadLog(0, 6) = %q, %v, want %q", got, err, "second")
+	}
+	if got, err := l.ReadTailLines(10); err != nil || !reflect.DeepEqual(got, []string{"second", "third", "fourth"}) {
+		t.Errorf("ReadTailLines = %q, %v, want the 3 records kept", got, err)
+	}
+	if n, err := l.LineCount(); err != nil || n != 3 {
+		t.Errorf("LineCount = %d, %v, want 3", n, err)
+	}
+	if err := l.ClearCurLogFile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.WriteLine("after clear"); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.String(); got != "after clear\n" {
+		t.Errorf("after a clear kept %q, want %q", got, "after clear\n")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("mmap is not supported on this system")
+
+// mapping is a read only mapping of a log file. It is unmapped once it has
+// been replaced and the last read using it is done
+type mapping struct {
+	name string
+	data []byte
+	// the reads using it, plus one while it is the mapping of the logger
+	refs int
+}
+
+// mappedFile is a mapping of the current log file, read like a rangeFile.
+// It keeps the mapping alive until it is closed, the reads themselves run
+// without mmapLock
+type mappedFile struct {
+	*bytes.Reader
+	l *FileLogger
+	m *mapping
+}
+
+func (f mappedFile) Close() error {
+	f.l.mmapLock.Lock()
+	f.l.release(f.m)
+	f.l.mmapLock.Unlock()
+	return nil
+}
+
+// open the current log file for a read made under the lock, from its
+// mapping with WithMmap
+func (l *FileLogger) openCurrentRange() (rangeFile, int64, error) {
+	fileName := l.currentLogFile()
+	//nothing unmaps the file once the logger is closed
+	if !l.mmap || l.aead != nil || l.closed {
+		return openRange(l.fs, fileName, l.aead)
+	}
+
+	fileInfo, err := l.fs.Stat(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := fileInfo.Size()
+
+	l.mmapLock.Lock()
+	defer l.mmapLock.Unlock()
+	//map again a file which grew or shrank since it was mapped, the reads
+	//of the previous mapping keep it until they are done
+	m := l.mapping
+	if m == nil || m.name != fileName || int64(len(m.data)) != size {
+		l.unmapLocked()
+		m, err = l.mapFile(fileName, size)
+		if err != nil {
+			if err != errMmapUnsupported {
+				l.handleError(err)
+			}
+			return openRange(l.fs, fileName, l.aead)
+		}
+		l.mapping = m
+	}
+	m.refs++
+	return mappedFile{Reader: bytes.NewReader(m.data), l: l, m: m}, size, nil
+}
+
+// map size bytes of fileName
+func (l *FileLogger) mapFile(fileName string, size int64) (*mapping, error) {
+	if size == 0 {
+		return &mapping{name: fileName, refs: 1}, nil
+	}
+	f, err := l.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := mmapFile(f, size)
+	if err != nil {
+		return nil, err
+	}
+	return &mapping{name: fileName, data: data, refs: 1}, nil
+}
+
+// drop the mapping of the current log file before it is closed
+func (l *FileLogger) unmap() {
+	if !l.mmap {
+		return
+	}
+	l.mmapLock.Lock()
+	l.unmapLocked()
+	l.mmapLock.Unlock()
+}
+
+// the part of unmap made under mmapLock
+func (l *FileLogger) unmapLocked() {
+	if l.mapping != nil {
+		l.release(l.mapping)
+		l.mapping = nil
+	}
+}
+
+// drop a reference to m and unmap it with the last one, mmapLock must be
+// held
+func (l *FileLogger) release(m *mapping) {
+	m.refs--
+	if m.refs > 0 || m.data == nil {
+		return
+	}
+	if err := munmapFile(m.data); err != nil {
+		l.handleError(err)
+	}
+	m.data = nil
+}
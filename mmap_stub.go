@@ -0,0 +1,16 @@
+//go:build !unix
+
+package core
+
+import (
+	"os"
+)
+
+// mmapFile always fails, the reads fall back to ReadAt
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmapFile(data []byte) error {
+	return nil
+}
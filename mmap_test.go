@@ -0,0 +1,64 @@
+package core
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMmapConcurrentReads(t *testing.T) {
+	l := newTestLogger(t, 0, 0, WithMmap(true))
+	writeTestLines(t, l, 10, 10)
+	f, size, err := l.openCurrentRange()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, ok := f.(mappedFile)
+	if !ok {
+		f.Close()
+		t.Skip("mmap is not supported")
+	}
+
+	//a read in progress does not hold up the others
+	done := make(chan error)
+	go func() {
+		_, err := l.ReadLog(0, 0)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadLog waited for another read to finish")
+	}
+
+	//the file grows, the next read maps it again while the first read
+	//keeps its mapping
+	writeTestLines(t, l, 1, 10)
+	if got, err := l.ReadLog(-10, 0); err != nil || got != "000000000\n" {
+		t.Fatalf("ReadLog after a write = %q, %v", got, err)
+	}
+	if l.mapping == first.m || len(l.mapping.data) != int(size)+10 {
+		t.Fatalf("mapping of %d bytes, want a new one of %d", len(l.mapping.data), size+10)
+	}
+	b, err := io.ReadAll(io.NewSectionReader(first, 0, size))
+	if err != nil || len(b) != int(size) {
+		t.Fatalf("read %d bytes, %v from the previous mapping, want %d", len(b), err, size)
+	}
+	if first.m.data == nil {
+		t.Fatal("the previous mapping was unmapped while read")
+	}
+	first.Close()
+	if first.m.data != nil {
+		t.Error("the previous mapping was not unmapped by its last read")
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if l.mapping != nil {
+		t.Error("mapping left after Close")
+	}
+}
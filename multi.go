@@ -0,0 +1,135 @@
+package core
+
+import (
+	"errors"
+)
+
+// MultiLogger writes the same stream to several loggers, like a tee. The
+// read and clear methods go to the first logger that is not a NullLogger.
+// The labels of its Labeled loggers are given to the LabeledWriter ones with
+// every record, so a sink shared by several MultiLoggers tells the records
+// of each apart
+type MultiLogger struct {
+	loggers []Logger
+	labels  map[string]string
+}
+
+func NewMultiLogger(loggers []Logger) *MultiLogger {
+	l := &MultiLogger{loggers: append([]Logger(nil), loggers...)}
+	for _, logger := range l.loggers {
+		if labeled, ok := logger.(Labeled); ok {
+			l.labels = mergeLabels(l.labels, labeled.Labels())
+		}
+	}
+	return l
+}
+
+// Write writes p to every logger, even after one of them failed, and returns
+// the first error
+func (l *MultiLogger) Write(p []byte) (int, error) {
+	return l.WriteLabeled(nil, p)
+}
+
+// WriteLabeled writes p like Write, giving the LabeledWriter loggers labels
+// over the ones of the MultiLogger
+func (l *MultiLogger) WriteLabeled(labels map[string]string, p []byte) (int, error) {
+	if len(labels) > 0 {
+		labels = mergeLabels(l.labels, labels)
+	} else {
+		labels = l.labels
+	}
+	var first error
+	n := len(p)
+	for _, logger := range l.loggers {
+		var m int
+		var err error
+		if w, ok := logger.(LabeledWriter); ok && len(labels) > 0 {
+			m, err = w.WriteLabeled(labels, p)
+		} else {
+			m, err = logger.Write(p)
+		}
+		if err != nil && first == nil {
+			first = err
+			n = m
+		}
+	}
+	return n, first
+}
+
+func (l *MultiLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *MultiLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+// Labels returns the labels of the loggers that have some, merged in a new
+// map. A label set by several of them has the value of the last one
+func (l *MultiLogger) Labels() map[string]string {
+	return mergeLabels(nil, l.labels)
+}
+
+// Close closes every logger and returns all their errors joined
+func (l *MultiLogger) Close() error {
+	errs := make([]error, 0)
+	for _, logger := range l.loggers {
+		if err := logger.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync syncs every logger and returns the first error
+func (l *MultiLogger) Sync() error {
+	var first error
+	for _, logger := range l.loggers {
+		if err := logger.Sync(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// return the logger the read and clear methods go to
+func (l *MultiLogger) reader() Logger {
+	for _, logger := range l.loggers {
+		if _, ok := logger.(*NullLogger); !ok {
+			return logger
+		}
+	}
+	return NewNullLogger()
+}
+
+func (l *MultiLogger) ReadLog(offset int64, length int64) (string, error) {
+	return l.reader().ReadLog(offset, length)
+}
+
+func (l *MultiLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return l.reader().ReadTailLog(offset, length)
+}
+
+func (l *MultiLogger) ReadTailLines(n int) ([]string, error) {
+	return l.reader().ReadTailLines(n)
+}
+
+func (l *MultiLogger) LineCount() (int64, error) {
+	return l.reader().LineCount()
+}
+
+func (l *MultiLogger) Stats() LoggerStats {
+	return l.reader().Stats()
+}
+
+func (l *MultiLogger) ClearCurLogFile() error {
+	return l.reader().ClearCurLogFile()
+}
+
+func (l *MultiLogger) ClearAllLogFile() error {
+	return l.reader().ClearAllLogFile()
+}
+
+func (l *MultiLogger) ClearOldestBackup() (string, error) {
+	return l.reader().ClearOldestBackup()
+}
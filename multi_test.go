@@ -0,0 +1,55 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// a logger writing only the first n bytes of every record, then failing
+type shortLogger struct {
+	*core.MemoryLogger
+	n   int
+	err error
+}
+
+func (l *shortLogger) Write(p []byte) (int, error) {
+	if len(p) <= l.n {
+		return l.MemoryLogger.Write(p)
+	}
+	l.MemoryLogger.Write(p[:l.n])
+	return l.n, l.err
+}
+
+func (l *shortLogger) Close() error {
+	return l.err
+}
+
+func TestMultiLoggerPartialWrite(t *testing.T) {
+	errShort := errors.New("disk full")
+	first, last := core.NewMemoryLogger(), core.NewMemoryLogger()
+	short := &shortLogger{MemoryLogger: core.NewMemoryLogger(), n: 3, err: errShort}
+	l := core.NewMultiLogger([]core.Logger{first, short, last})
+
+	n, err := l.Write([]byte("record\n"))
+	if !errors.Is(err, errShort) || n != 3 {
+		t.Errorf("Write = %d, %v, want 3 and the error of the short write", n, err)
+	}
+	if first.String() != "record\n" || last.String() != "record\n" {
+		t.Errorf("got %q and %q, want the record written to the other loggers", first.String(), last.String())
+	}
+	if got := short.String(); got != "rec" {
+		t.Errorf("short logger got %q, want %q", got, "rec")
+	}
+
+	if n, err = l.WriteLine("ok"); err != nil || n != 3 {
+		t.Errorf("WriteLine = %d, %v, want 3 and no error", n, err)
+	}
+	if got, _ := l.ReadLog(0, 0); got != "record\nok\n" {
+		t.Errorf("ReadLog = %q, want the first logger content", got)
+	}
+	if err := l.Close(); !errors.Is(err, errShort) {
+		t.Errorf("Close = %v, want the error of the short logger", err)
+	}
+}
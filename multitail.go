@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// size of the buffer between the followed loggers and the MultiTail consumer
+const multiTailBuffer = 1024
+
+// TaggedLine is a line of a MultiTail stream
+type TaggedLine struct {
+	// Source is the name of the logger the line was written to
+	Source string
+	Line   string
+	// Dropped is the number of lines of the same source dropped just before
+	// this one because the consumer was too slow
+	Dropped int64
+}
+
+// followedLogger is a Logger whose new lines can be streamed
+type followedLogger interface {
+	Logger
+	Name() string
+	FollowWithReplay(ctx context.Context, replayBytes int64) (<-chan string, error)
+}
+
+// MultiTail merges the live tails of several loggers into one channel, each
+// line tagged with the name of its logger. Every source follows its own
+// rotations. The sources never wait for the consumer: when the buffer is
+// full their lines are dropped and counted in the next line delivered for
+// the same source. The channel is closed once ctx is done and all the
+// sources stopped. All the loggers must support following, like FileLogger
+func MultiTail(ctx context.Context, loggers []Logger) (<-chan TaggedLine, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	sources := make([]<-chan string, 0, len(loggers))
+	names := make([]string, 0, len(loggers))
+	for _, logger := range loggers {
+		fl, ok := logger.(followedLogger)
+		if !ok {
+			cancel()
+			return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+		}
+		lines, err := fl.FollowWithReplay(ctx, 0)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		sources = append(sources, lines)
+		names = append(names, fl.Name())
+	}
+
+	out := make(chan TaggedLine, multiTailBuffer)
+	var wg sync.WaitGroup
+	for i := range sources {
+		wg.Add(1)
+		go func(name string, lines <-chan string) {
+			defer wg.Done()
+			dropped := int64(0)
+			for line := range lines {
+				select {
+				case out <- TaggedLine{Source: name, Line: line, Dropped: dropped}:
+					dropped = 0
+				default:
+					dropped++
+				}
+			}
+		}(names[i], sources[i])
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out, nil
+}
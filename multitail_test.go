@@ -0,0 +1,82 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestMultiTail(t *testing.T) {
+	dir := t.TempDir()
+	access := newLogger(t, filepath.Join(dir, "access.log"), core.WithMaxSize(40), core.WithBackups(3))
+	errorLog := newLogger(t, filepath.Join(dir, "error.log"))
+	write(t, access, "before\n")
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, err := core.MultiTail(ctx, []core.Logger{access, errorLog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//access rotates every 4 lines on the way. The lines of a round are
+	//received before the next one is written, as a file rotated in and out
+	//between two polls would be skipped
+	var want, got [2][]string
+	sources := map[string]int{access.Name(): 0, errorLog.Name(): 1}
+	for i := 0; i < 8; i++ {
+		n := 1
+		want[0] = append(want[0], fmt.Sprintf("GET /%d 200", i))
+		write(t, access, want[0][i]+"\n")
+		if i%3 == 0 {
+			n++
+			want[1] = append(want[1], fmt.Sprintf("error %d", i))
+			write(t, errorLog, want[1][len(want[1])-1]+"\n")
+		}
+		for ; n > 0; n-- {
+			select {
+			case line := <-lines:
+				src, ok := sources[line.Source]
+				if !ok {
+					t.Fatalf("line %q from unknown source %q", line.Line, line.Source)
+				}
+				if line.Dropped != 0 {
+					t.Errorf("%d lines dropped before %q", line.Dropped, line.Line)
+				}
+				got[src] = append(got[src], line.Line)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("got %q, still waiting for %d lines", got, n)
+			}
+		}
+	}
+	//every source keeps its order, across the rotations of access
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	cancel()
+	select {
+	case line, ok := <-lines:
+		if ok {
+			t.Fatalf("line %+v after the cancel", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream was not closed after the cancel")
+	}
+	eventually(t, "the followers to stop", func() bool { return runtime.NumGoroutine() <= goroutines })
+}
+
+func TestMultiTailNotFollowable(t *testing.T) {
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"))
+	_, err := core.MultiTail(context.Background(), []core.Logger{l, core.NewMemoryLogger()})
+	var fault *core.Fault
+	if !errors.As(err, &fault) || fault.Code != core.BAD_ARGUMENTS {
+		t.Errorf("MultiTail with a MemoryLogger = %v, want a BAD_ARGUMENTS fault", err)
+	}
+}
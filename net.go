@@ -0,0 +1,247 @@
+package core
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// first and longest wait of a NetLogger before trying to reconnect
+	netMinBackoff = time.Second
+	netMaxBackoff = time.Minute
+	// dial and write timeout of a NetLogger created without one
+	netDefaultTimeout = 5 * time.Second
+)
+
+// NetLogger writes every record to a TCP or UDP endpoint. Each write is
+// given at most timeout to complete, so a stalled peer never blocks the
+// caller for long. When the connection fails, it is tried again on the next
+// writes with an exponential backoff, and meanwhile the records are either
+// dropped or kept in memory, up to maxBuffered bytes with the oldest ones
+// dropped first, to be sent once connected again.
+//
+// There is no file to read or clear, those methods return a NO_FILE Fault
+type NetLogger struct {
+	network     string
+	addr        string
+	timeout     time.Duration
+	maxBuffered int
+
+	lock        sync.Mutex
+	conn        net.Conn
+	pending     [][]byte
+	pendingSize int
+	dropped     int64
+	written     int64
+	backoff     time.Duration
+	retryAt     time.Time
+	closed      bool
+}
+
+// NewNetLogger creates a NetLogger sending to addr over network, "tcp" or
+// "udp" for example. The connection is made by the first write. A zero
+// maxBuffered drops the records while disconnected. A timeout that is not
+// positive gives the dial and each write 5 seconds
+func NewNetLogger(network string, addr string, timeout time.Duration, maxBuffered int) *NetLogger {
+	if timeout <= 0 {
+		timeout = netDefaultTimeout
+	}
+	return &NetLogger{network: network,
+		addr:        addr,
+		timeout:     timeout,
+		maxBuffered: maxBuffered}
+}
+
+// connect to the endpoint if not done yet
+func (l *NetLogger) connect() error {
+	if l.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout(l.network, l.addr, l.timeout)
+	if err != nil {
+		return err
+	}
+	l.conn = conn
+	l.backoff = 0
+	return nil
+}
+
+// drop the connection and wait before trying again
+func (l *NetLogger) disconnect() {
+	if l.conn != nil {
+		l.conn.Close()
+		l.conn = nil
+	}
+	if l.backoff == 0 {
+		l.backoff = netMinBackoff
+	} else if l.backoff < netMaxBackoff {
+		l.backoff *= 2
+		if l.backoff > netMaxBackoff {
+			l.backoff = netMaxBackoff
+		}
+	}
+	l.retryAt = time.Now().Add(l.backoff)
+}
+
+// keep a record until the connection is back, dropping the oldest ones
+func (l *NetLogger) keep(p []byte) {
+	if l.maxBuffered <= 0 {
+		l.dropped++
+		return
+	}
+	l.pending = append(l.pending, append([]byte(nil), p...))
+	l.pendingSize += len(p)
+	for l.pendingSize > l.maxBuffered && len(l.pending) > 0 {
+		l.pendingSize -= len(l.pending[0])
+		l.pending = l.pending[1:]
+		l.dropped++
+	}
+}
+
+// write a record within the timeout, return how much was written
+func (l *NetLogger) send(p []byte) (int, error) {
+	l.conn.SetWriteDeadline(time.Now().Add(l.timeout))
+	n, err := l.conn.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+// write the records kept while disconnected, a partly written record is
+// kept for its rest
+func (l *NetLogger) flushPending() error {
+	for len(l.pending) > 0 {
+		n, err := l.send(l.pending[0])
+		l.pendingSize -= n
+		if err != nil {
+			l.pending[0] = l.pending[0][n:]
+			return err
+		}
+		l.pending = l.pending[1:]
+	}
+	return nil
+}
+
+// Write sends p to the endpoint, or keeps or drops it if it can't be reached
+func (l *NetLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, ErrLoggerClosed
+	}
+	//p is all taken, sent or kept, even when it is cut by a failed send
+	size := len(p)
+	if l.conn == nil && time.Now().After(l.retryAt) {
+		if err := l.connect(); err != nil {
+			l.disconnect()
+		}
+	}
+	if l.conn != nil {
+		n := 0
+		err := l.flushPending()
+		if err == nil {
+			n, err = l.send(p)
+			if err == nil {
+				return size, nil
+			}
+		}
+		l.disconnect()
+		p = p[n:]
+	}
+	l.keep(p)
+	return size, nil
+}
+
+// WriteLabeled sends p like Write, preceded by the labels as `key=value`
+// pairs sorted by key, the way WithLabelPrefix writes them in a file
+func (l *NetLogger) WriteLabeled(labels map[string]string, p []byte) (int, error) {
+	prefix := formatLabels(labels)
+	if prefix == nil {
+		return l.Write(p)
+	}
+	if _, err := l.Write(append(prefix, p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (l *NetLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *NetLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+// Dropped returns the number of records dropped while disconnected
+func (l *NetLogger) Dropped() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.dropped
+}
+
+// Close tries once to send the kept records, then closes the connection
+func (l *NetLogger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return ErrLoggerClosed
+	}
+	l.closed = true
+	var err error
+	if len(l.pending) > 0 {
+		if err = l.connect(); err == nil {
+			err = l.flushPending()
+		}
+	}
+	if l.conn != nil {
+		if e := l.conn.Close(); err == nil {
+			err = e
+		}
+		l.conn = nil
+	}
+	return err
+}
+
+func (l *NetLogger) ReadLog(offset int64, length int64) (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *NetLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *NetLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *NetLogger) LineCount() (int64, error) {
+	return 0, NewFault(NO_FILE, "NO_FILE")
+}
+
+// Stats returns the bytes sent, CurrentFile is the address of the endpoint
+func (l *NetLogger) Stats() LoggerStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return LoggerStats{BytesWritten: l.written, CurrentFile: l.addr}
+}
+
+func (l *NetLogger) Sync() error {
+	return nil
+}
+
+func (l *NetLogger) ClearCurLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *NetLogger) ClearAllLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *NetLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNetLoggerDefaultTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if l := NewNetLogger("tcp", "127.0.0.1:0", timeout, 0); l.timeout != netDefaultTimeout {
+			t.Errorf("timeout %v: got %v, want %v", timeout, l.timeout, netDefaultTimeout)
+		}
+	}
+}
+
+func TestNetLoggerStalledPeer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	l := NewNetLogger("tcp", ln.Addr().String(), 100*time.Millisecond, 64<<20)
+	record := bytes.Repeat([]byte("x"), 64<<10)
+	var stalled net.Conn
+	//the peer never reads, so the socket buffers fill up and a write times out
+	start := time.Now()
+	for i := 0; i < 512; i++ {
+		if _, err := l.Write(record); err != nil {
+			t.Fatal(err)
+		}
+		if stalled == nil {
+			stalled = <-conns
+		}
+		l.lock.Lock()
+		disconnected := l.conn == nil
+		l.lock.Unlock()
+		if disconnected {
+			break
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("writes to a stalled peer took %v", elapsed)
+	}
+	l.lock.Lock()
+	if l.conn != nil || l.pendingSize == 0 {
+		t.Fatalf("conn = %v, pending %d bytes, want a kept record after a write timeout", l.conn, l.pendingSize)
+	}
+	pending := l.pendingSize
+	l.lock.Unlock()
+
+	//the peer goes away, the records kept go to the next connection
+	stalled.Close()
+	l.lock.Lock()
+	l.retryAt = time.Time{}
+	l.lock.Unlock()
+	received := make(chan []byte)
+	go func() {
+		conn := <-conns
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+	if _, err := l.Write([]byte("end\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := <-received
+	if len(b) != pending+4 || !bytes.HasSuffix(b, []byte("xend\n")) {
+		t.Errorf("received %d bytes, want the %d kept and the last record", len(b), pending)
+	}
+	if _, err := l.Write([]byte("late\n")); err != ErrLoggerClosed {
+		t.Errorf("Write after Close = %v, want ErrLoggerClosed", err)
+	}
+}
+
+// shortConn accepts n bytes, then fails every write
+type shortConn struct {
+	net.Conn
+	n       int
+	written bytes.Buffer
+}
+
+func (c *shortConn) Write(p []byte) (int, error) {
+	if len(p) > c.n {
+		c.written.Write(p[:c.n])
+		n := c.n
+		c.n = 0
+		return n, io.ErrClosedPipe
+	}
+	c.n -= len(p)
+	return c.written.Write(p)
+}
+
+func (c *shortConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *shortConn) Close() error {
+	return nil
+}
+
+func TestNetLoggerPartialWrite(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	l := NewNetLogger("tcp", ln.Addr().String(), time.Second, 1<<10)
+	conn := &shortConn{n: 6}
+	l.conn = conn
+	record := []byte("first record\n")
+	if n, err := l.Write(record); n != len(record) || err != nil {
+		t.Fatalf("Write = %d, %v, want %d, nil", n, err, len(record))
+	}
+	if got := conn.written.String(); got != "first " {
+		t.Fatalf("sent %q before the failure, want %q", got, "first ")
+	}
+	if l.conn != nil || l.pendingSize != len(record)-6 {
+		t.Fatalf("conn = %v, pending %d bytes, want the %d bytes left kept", l.conn, l.pendingSize, len(record)-6)
+	}
+
+	//the rest of the cut record goes first to the next connection
+	received := make(chan []byte)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+	l.retryAt = time.Time{}
+	if n, err := l.Write([]byte("second\n")); n != 7 || err != nil {
+		t.Fatalf("Write after reconnecting = %d, %v, want 7, nil", n, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b := <-received; string(b) != "record\nsecond\n" {
+		t.Errorf("received %q, want %q", b, "record\nsecond\n")
+	}
+
+	//io.Copy takes a short count as an error
+	l = NewNetLogger("tcp", ln.Addr().String(), time.Second, 1<<10)
+	l.conn = &shortConn{n: 3}
+	defer l.Close()
+	if n, err := io.Copy(l, struct{ io.Reader }{bytes.NewReader(record)}); n != int64(len(record)) || err != nil {
+		t.Errorf("io.Copy = %d, %v, want %d, nil", n, err, len(record))
+	}
+}
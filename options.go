@@ -0,0 +1,247 @@
+package core
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// the size and number of the files of NewFileLoggerWithOptions
+const (
+	defaultMaxSize = 10 << 20
+	defaultBackups = 5
+)
+
+// Option configures a FileLogger
+type Option func(*FileLogger)
+
+// WithFooter sets a function whose result is written to a log file just
+// before the file is closed, either on rotation or on Close
+func WithFooter(footer func() []byte) Option {
+	return func(l *FileLogger) {
+		l.footer = footer
+	}
+}
+
+// WithHeader sets a function whose result is written at the start of every
+// new log file, the first one and those opened by a rotation or a clear. A
+// file reopened to append to it gets no header
+func WithHeader(header func() []byte) Option {
+	return func(l *FileLogger) {
+		l.header = header
+	}
+}
+
+// WithFileMode sets the permission of the created log files, 0644 by
+// default. The umask of the process still applies
+func WithFileMode(mode os.FileMode) Option {
+	return func(l *FileLogger) {
+		l.fileMode = mode
+	}
+}
+
+// WithDirMode sets the permission of the log directory when the logger
+// creates it, 0755 by default
+func WithDirMode(mode os.FileMode) Option {
+	return func(l *FileLogger) {
+		l.dirMode = mode
+	}
+}
+
+// WithPreserveMode makes a rotation give the new log file the permission of
+// the file being rotated out, so a mode changed by an operator is kept.
+// The first file gets the configured permission
+func WithPreserveMode(preserve bool) Option {
+	return func(l *FileLogger) {
+		l.preserveMode = preserve
+	}
+}
+
+// WithForceRotateAfter rotates the log file after exactly n writes whatever
+// its size. It is a testing aid to exercise the rotation deterministically,
+// together with WithClock and WithFS, and not meant for production use
+func WithForceRotateAfter(n int) Option {
+	return func(l *FileLogger) {
+		l.forceRotateAfter = n
+	}
+}
+
+// WithErrorHandler sets a function called with the errors no caller can
+// see, like a failed rotation, and with the invariant violations when the
+// logger does not panic. It is called with the lock held so it must not
+// use the logger
+func WithErrorHandler(handler func(error)) Option {
+	return func(l *FileLogger) {
+		l.errorHandler = handler
+	}
+}
+
+// WithPanicOnError makes the logger panic on an invariant violation, to
+// fail loudly during development. The only invariant checked for now is
+// writing while no log file is open and it can't be reopened, after a
+// failed open or rotation. By default the logger does not panic: the
+// violation goes to the error handler and the write returns an error
+func WithPanicOnError(panicOnError bool) Option {
+	return func(l *FileLogger) {
+		l.panicOnError = panicOnError
+	}
+}
+
+// WithValidateUTF8 checks that every write is valid UTF-8. An invalid write
+// is rejected with a BAD_ARGUMENTS fault, or written with each run of
+// invalid bytes replaced by U+FFFD if replace is true. Each write is checked
+// on its own, so a rune must not be split across two writes
+func WithValidateUTF8(replace bool) Option {
+	return func(l *FileLogger) {
+		l.validateUTF8 = true
+		l.replaceUTF8 = replace
+	}
+}
+
+// WithBufferSize buffers up to size bytes of writes in memory before they
+// reach the log file, to save system calls. The buffered data is written on
+// rotation and Close, or every WithFlushInterval, and is not seen by the
+// read methods before that
+func WithBufferSize(size int) Option {
+	return func(l *FileLogger) {
+		l.bufSize = size
+	}
+}
+
+// WithFlushOnNewline writes the buffer to the file after every write that
+// contains a newline, so the complete lines show up promptly while partial
+// output is still batched. It only applies together with WithBufferSize
+func WithFlushOnNewline(flush bool) Option {
+	return func(l *FileLogger) {
+		l.flushOnNewline = flush
+	}
+}
+
+// WithMmap serves ReadLog, ReadTailLog and the other reads of the current
+// log file from a read only mapping of it, mapped again when the file grows
+// or rotates, instead of opening the file on every read. It saves the
+// system calls of a viewer reading the same file many times a second. An
+// encrypted logger and the systems without mmap read the file as usual.
+// Another process truncating the file under the mapping makes a read fault
+func WithMmap(mmap bool) Option {
+	return func(l *FileLogger) {
+		l.mmap = mmap
+	}
+}
+
+// WithFlushInterval writes the buffer to the file every interval, so the
+// data of an idle logger is not held in memory until the next rotation. It
+// only applies together with WithBufferSize, the ticks come from the logger
+// clock when it is a TickerClock and Close stops them
+func WithFlushInterval(interval time.Duration) Option {
+	return func(l *FileLogger) {
+		l.flushInterval = interval
+	}
+}
+
+// WithRotateInterval rotates the log file once it is older than interval,
+// whatever its size, for example to get a file per day. The age is checked
+// by the next write, which then goes to the new file, so an idle logger
+// creates no empty file. The time is read from the logger clock
+func WithRotateInterval(interval time.Duration) Option {
+	return func(l *FileLogger) {
+		l.rotateInterval = interval
+	}
+}
+
+// WithWatchExternalChanges checks before every write that the log file on
+// disk is still the one being written and not shorter than what was
+// written, and reopens it otherwise, so a file removed or truncated by hand
+// is written again. It costs two stat calls per write
+func WithWatchExternalChanges(watch bool) Option {
+	return func(l *FileLogger) {
+		l.watchExternal = watch
+	}
+}
+
+// WithMaxTotalSize removes the oldest backups after a rotation until all
+// the log files together take at most size bytes on disk, along with the
+// limit on the number of files. The current file is never removed, even if
+// it is larger than size alone
+func WithMaxTotalSize(size int64) Option {
+	return func(l *FileLogger) {
+		l.maxTotalSize = size
+	}
+}
+
+// WithMaxAge removes after a rotation the backups last modified more than
+// age ago, by the logger clock. The current file is never removed, however
+// old it is
+func WithMaxAge(age time.Duration) Option {
+	return func(l *FileLogger) {
+		l.maxAge = age
+	}
+}
+
+// WithOnRotate calls f with the name of each file rotated out, once the
+// writer released the lock so f can use the logger. The calls never overlap
+// and come in the order of the rotations; a panic in f goes to the error
+// handler. With WithCompress the file may already be replaced by its
+// compressed version when f runs
+func WithOnRotate(f func(oldFile string)) Option {
+	return func(l *FileLogger) {
+		l.onRotate = f
+	}
+}
+
+// WithSyncOnWrite commits every write to stable storage before it returns,
+// see Sync. No record is lost in a crash, but each write then costs an
+// fsync, which can make the logger a hundred times slower
+func WithSyncOnWrite(sync bool) Option {
+	return func(l *FileLogger) {
+		l.syncOnWrite = sync
+	}
+}
+
+// WithTimestampPrefix writes the time of the logger clock, followed by a
+// space, in front of every record. A record is what one write gets, so a
+// write of several lines has a single timestamp at its start and a line
+// made of several writes has one for each of them. The time is formatted
+// with time.RFC3339 unless WithTimestampFormat sets another layout
+func WithTimestampPrefix(prefix bool) Option {
+	return func(l *FileLogger) {
+		l.timestampPrefix = prefix
+	}
+}
+
+// WithTimestampFormat sets the time layout of WithTimestampPrefix
+func WithTimestampFormat(layout string) Option {
+	return func(l *FileLogger) {
+		l.timestampLayout = layout
+	}
+}
+
+// WithMaxSize sets the size a log file is rotated at, 0 never rotates by
+// size
+func WithMaxSize(maxSize int64) Option {
+	return func(l *FileLogger) {
+		l.maxSize = maxSize
+	}
+}
+
+// WithBackups sets the number of log files kept, 0 never rotates at all
+func WithBackups(backups int) Option {
+	return func(l *FileLogger) {
+		l.backups = backups
+	}
+}
+
+// WithLocker sets the lock of the logger state, see NewFileLogger
+func WithLocker(locker sync.Locker) Option {
+	return func(l *FileLogger) {
+		l.locker = locker
+	}
+}
+
+// WithTruncateOnStart empties the current log file at construction instead
+// of appending to what a previous run left in it. The backups are kept
+func WithTruncateOnStart(truncate bool) Option {
+	return func(l *FileLogger) {
+		l.truncateOnStart = truncate
+	}
+}
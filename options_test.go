@@ -0,0 +1,81 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestTruncateOnStart(t *testing.T) {
+	for _, truncate := range []bool{false, true} {
+		name := filepath.Join(t.TempDir(), "test.log")
+		populate(t, [2]string{name + ".0", "backup\n"}, [2]string{name + ".1", "previous run\n"})
+
+		l := newLogger(t, name, core.WithMaxSize(100), core.WithBackups(3), core.WithTruncateOnStart(truncate))
+		if got := l.GetCurrentLogFile(); got != name+".1" {
+			t.Fatalf("truncate %v: current file %s, want the latest one", truncate, got)
+		}
+		want := "previous run\n"
+		if truncate {
+			want = ""
+		}
+		if got, _ := l.ReadLog(0, 0); got != want {
+			t.Errorf("truncate %v: ReadLog = %q, want %q", truncate, got, want)
+		}
+		if n := l.Stats().CurrentFileSize; n != int64(len(want)) {
+			t.Errorf("truncate %v: size %d, want %d", truncate, n, len(want))
+		}
+		if got := readFile(t, name+".0"); got != "backup\n" {
+			t.Errorf("truncate %v: backup %q, want it kept", truncate, got)
+		}
+		write(t, l, "new\n")
+		if got, _ := l.ReadLog(0, 0); got != want+"new\n" {
+			t.Errorf("truncate %v: ReadLog = %q after a write", truncate, got)
+		}
+	}
+}
+
+func TestTruncateOnStartWithoutFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	l := newLogger(t, filepath.Join(dir, "test.log"), core.WithTruncateOnStart(true))
+	if _, err := os.Stat(l.GetCurrentLogFile()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := l.ReadLog(0, 0); got != "" {
+		t.Errorf("ReadLog = %q, want an empty file", got)
+	}
+}
+
+func TestHeaderAfterRotations(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	header := func() []byte { return []byte("# header\n") }
+	l := newLogger(t, name, core.WithMaxSize(20), core.WithBackups(4), core.WithHeader(header))
+	//each record fills the file with the header, so two rotate twice
+	write(t, l, "first record\n", "second recrd\n")
+	if n := l.Stats().RotationCount; n != 2 {
+		t.Fatalf("%d rotations, want 2", n)
+	}
+	for file, want := range map[string]string{
+		name + ".0": "# header\nfirst record\n",
+		name + ".1": "# header\nsecond recrd\n",
+		name + ".2": "# header\n",
+	} {
+		if got := readFile(t, file); got != want {
+			t.Errorf("%s holds %q, want %q", file, got, want)
+		}
+	}
+	//the header counts toward the size of the file
+	if n := l.Stats().CurrentFileSize; n != int64(len("# header\n")) {
+		t.Errorf("size %d, want the size of the header", n)
+	}
+	l.Close()
+
+	//a file continued by a restart gets no second header
+	l = newLogger(t, name, core.WithMaxSize(20), core.WithBackups(4), core.WithHeader(header))
+	write(t, l, "third\n")
+	if got, _ := l.ReadLog(0, 0); got != "# header\nthird\n" {
+		t.Errorf("ReadLog = %q after a restart", got)
+	}
+}
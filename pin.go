@@ -0,0 +1,60 @@
+package core
+
+import (
+	"io"
+	"os"
+)
+
+// PinCurrent defers the rotation of the current log file until
+// UnpinCurrent is called as many times, so a consistent copy of it can be
+// taken. The writes go on while the file is pinned, so it can grow past the
+// maximum size; the rotation happens on the last UnpinCurrent. It returns
+// the name of the pinned file
+func (l *FileLogger) PinCurrent() string {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	l.pins++
+	return l.currentLogFile()
+}
+
+// UnpinCurrent releases a pin taken by PinCurrent and rotates the current
+// log file if it became due while pinned
+func (l *FileLogger) UnpinCurrent() {
+	defer l.notifyRotated()
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if l.pins == 0 {
+		return
+	}
+	l.pins--
+	if l.pins == 0 && l.file != nil && l.shouldRotate() {
+		if err := l.doRotate(); err != nil {
+			l.handleError(err)
+		}
+	}
+}
+
+// CopyLog copies the current log file to w while it is pinned, so it is not
+// rotated in the middle of the copy. The content written before the call is
+// copied, the pin is released even if the copy fails
+func (l *FileLogger) CopyLog(w io.Writer) (int64, error) {
+	name := l.PinCurrent()
+	defer l.UnpinCurrent()
+
+	l.locker.Lock()
+	l.flush()
+	f, err := l.fs.OpenFile(name, os.O_RDONLY, 0)
+	l.locker.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	statInfo, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, io.NewSectionReader(f, 0, statInfo.Size()))
+}
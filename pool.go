@@ -0,0 +1,51 @@
+package core
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// the read buffers are pooled by power of two sizes, from 4KB to 16MB, the
+// larger ones are not pooled
+const (
+	minPooledShift = 12
+	maxPooledShift = 24
+)
+
+var readPools [maxPooledShift - minPooledShift + 1]sync.Pool
+
+// return the pool of the buffers of at least n bytes, or -1 if n is too
+// large to be pooled
+func readPoolIndex(n int) int {
+	shift := minPooledShift
+	if n > 1<<minPooledShift {
+		shift = bits.Len(uint(n - 1))
+	}
+	if shift > maxPooledShift {
+		return -1
+	}
+	return shift - minPooledShift
+}
+
+// return a buffer of n bytes, to give back with putReadBuf once its content
+// was copied
+func getReadBuf(n int) []byte {
+	i := readPoolIndex(n)
+	if i < 0 {
+		return make([]byte, n)
+	}
+	if b, ok := readPools[i].Get().(*[]byte); ok {
+		return (*b)[:n]
+	}
+	return make([]byte, n, 1<<(i+minPooledShift))
+}
+
+// give back a buffer returned by getReadBuf
+func putReadBuf(b []byte) {
+	i := readPoolIndex(cap(b))
+	if i < 0 || cap(b) != 1<<(i+minPooledShift) {
+		return
+	}
+	b = b[:0]
+	readPools[i].Put(&b)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// how long a post-rotate command may run by default
+const defaultPostRotateTimeout = time.Minute
+
+// how long the output of a killed post-rotate command is waited for
+const postRotateWaitDelay = time.Second
+
+// WithPostRotateCommand runs command, a program and its arguments, after
+// each rotation, with every {} of the arguments replaced by the name of the
+// file rotated out. It runs in the background and is killed after timeout,
+// a minute if timeout is 0, so a hung command can't stall the logging. A
+// failure goes to the error handler and is written to the log with the
+// output of the command. Close waits for the commands still running. With
+// WithCompress the file may already be replaced by its compressed version
+// when the command runs
+func WithPostRotateCommand(command []string, timeout time.Duration) Option {
+	return func(l *FileLogger) {
+		l.postRotate = command
+		l.postRotateTimeout = timeout
+	}
+}
+
+// start the post-rotate command for a file just rotated out, the caller
+// must hold the lock
+func (l *FileLogger) postRotateLater(oldFile string) {
+	args := make([]string, len(l.postRotate))
+	for i, arg := range l.postRotate {
+		args[i] = strings.ReplaceAll(arg, "{}", oldFile)
+	}
+	timeout := l.postRotateTimeout
+	if timeout <= 0 {
+		timeout = defaultPostRotateTimeout
+	}
+	l.compressWG.Add(1)
+	go l.runPostRotate(args, timeout)
+}
+
+// run a post-rotate command without holding the lock and report its failure
+func (l *FileLogger) runPostRotate(args []string, timeout time.Duration) {
+	defer l.compressWG.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	//a child of the killed command may keep its output open
+	cmd.WaitDelay = postRotateWaitDelay
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return
+	}
+	err = fmt.Errorf("post-rotate command %q failed: %w", strings.Join(args, " "), err)
+	l.locker.Lock()
+	l.handleError(err)
+	l.locker.Unlock()
+	l.Write([]byte(fmt.Sprintf("%v: %s\n", err, strings.TrimSpace(string(out)))))
+}
@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestPostRotateCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the command")
+	}
+	dir := t.TempDir()
+	name := filepath.Join(dir, "test.log")
+	copied := filepath.Join(dir, "copied")
+	l, err := core.NewFileLoggerWithOptions(name, core.WithMaxSize(5), core.WithBackups(3),
+		core.WithPostRotateCommand([]string{"sh", "-c", `cat "$1" > "$2"`, "sh", "{}", copied}, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, "aaaa\n")
+	//Close waits for the command
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, copied); got != "aaaa\n" {
+		t.Errorf("the command got %q, want the file rotated out", got)
+	}
+}
+
+func TestPostRotateCommandFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the command")
+	}
+	tests := []struct {
+		name    string
+		command []string
+		timeout time.Duration
+		output  string
+	}{
+		{"exit status", []string{"sh", "-c", "echo oops; exit 3"}, 0, "exit status 3: oops"},
+		{"timeout", []string{"sh", "-c", "sleep 10"}, 50 * time.Millisecond, "killed"},
+		{"missing program", []string{filepath.Join(t.TempDir(), "missing")}, 0, "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var handled []error
+			name := filepath.Join(t.TempDir(), "test.log")
+			l, err := core.NewFileLoggerWithOptions(name, core.WithMaxSize(200), core.WithBackups(3),
+				core.WithPostRotateCommand(tt.command, tt.timeout),
+				core.WithErrorHandler(func(err error) {
+					lock.Lock()
+					handled = append(handled, err)
+					lock.Unlock()
+				}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			//the record fills the file, the failure written after it does not
+			write(t, l, strings.Repeat("a", 199)+"\n")
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Close waited %v", elapsed)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if len(handled) != 1 || !strings.Contains(handled[0].Error(), "post-rotate command") {
+				t.Fatalf("handled %v, want the failure of the command", handled)
+			}
+			if got := readFile(t, name+".1"); !strings.Contains(got, tt.output) {
+				t.Errorf("log %q, want the failure with %q", got, tt.output)
+			}
+		})
+	}
+}
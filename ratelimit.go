@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitLogger forwards at most n records per interval to an underlying
+// Logger and drops the others, so a flood of records can't fill the disk.
+// The limit is a token bucket: n records can be written at once, then one
+// more every interval/n. The next record written after some were dropped is
+// preceded by a "…suppressed M messages" line, and so is Close. The read
+// and clear methods go straight to the underlying logger
+type RateLimitLogger struct {
+	logger   Logger
+	n        int
+	interval time.Duration
+	clock    Clock
+
+	lock       sync.Mutex
+	tokens     float64
+	last       time.Time
+	suppressed int64
+	dropped    int64
+}
+
+// RateLimitOption configures a RateLimitLogger
+type RateLimitOption func(*RateLimitLogger)
+
+// WithRateLimitClock makes the logger read the time from clock to refill
+// its bucket, tests can provide their own
+func WithRateLimitClock(clock Clock) RateLimitOption {
+	return func(l *RateLimitLogger) {
+		l.clock = clock
+	}
+}
+
+// NewRateLimitLogger limits the records written to logger to n per interval.
+// An n below 1 or an interval that is not positive returns a BAD_ARGUMENTS
+// Fault
+func NewRateLimitLogger(logger Logger, n int, interval time.Duration, opts ...RateLimitOption) (*RateLimitLogger, error) {
+	if n < 1 || interval <= 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l := &RateLimitLogger{logger: logger, n: n, interval: interval, clock: realClock{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.tokens = float64(n)
+	l.last = l.clock.Now()
+	return l, nil
+}
+
+// take a token from the bucket, refilled for the time elapsed since the
+// last call. The caller must hold the lock
+func (l *RateLimitLogger) take() bool {
+	now := l.clock.Now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += float64(elapsed) / float64(l.interval) * float64(l.n)
+		if l.tokens > float64(l.n) {
+			l.tokens = float64(l.n)
+		}
+		l.last = now
+	}
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// write the summary of the records dropped since the last one, if any. The
+// caller must hold the lock
+func (l *RateLimitLogger) writeSuppressed() error {
+	if l.suppressed == 0 {
+		return nil
+	}
+	_, err := l.logger.Write([]byte(fmt.Sprintf("…suppressed %d messages\n", l.suppressed)))
+	l.suppressed = 0
+	return err
+}
+
+// Write forwards p if the bucket has a token left, and drops it otherwise.
+// A dropped record still reports len(p)
+func (l *RateLimitLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.take() {
+		l.suppressed++
+		l.dropped++
+		return len(p), nil
+	}
+	if err := l.writeSuppressed(); err != nil {
+		return 0, err
+	}
+	return l.logger.Write(p)
+}
+
+func (l *RateLimitLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+// WriteLines takes a token for each line like Write does for a record. The
+// lines kept are forwarded in batches, split where a summary of the lines
+// dropped must come first. A dropped line is still counted in the length
+// returned
+func (l *RateLimitLogger) WriteLines(ss []string) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	kept := make([]string, 0, len(ss))
+	total := 0
+	for _, s := range ss {
+		total += terminatedLen(s)
+		if !l.take() {
+			l.suppressed++
+			l.dropped++
+			continue
+		}
+		if l.suppressed > 0 {
+			if err := l.writeLines(kept); err != nil {
+				return 0, err
+			}
+			kept = kept[:0]
+			if err := l.writeSuppressed(); err != nil {
+				return 0, err
+			}
+		}
+		kept = append(kept, s)
+	}
+	if err := l.writeLines(kept); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// forward a batch of lines if there is any, the caller must hold the lock
+func (l *RateLimitLogger) writeLines(ss []string) error {
+	if len(ss) == 0 {
+		return nil
+	}
+	_, err := l.logger.WriteLines(ss)
+	return err
+}
+
+// Dropped returns how many records Write discarded since the logger was
+// created
+func (l *RateLimitLogger) Dropped() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.dropped
+}
+
+// Close writes the summary of the records dropped last, then closes the
+// underlying logger
+func (l *RateLimitLogger) Close() error {
+	l.lock.Lock()
+	err := l.writeSuppressed()
+	l.lock.Unlock()
+	if e := l.logger.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (l *RateLimitLogger) ReadLog(offset int64, length int64) (string, error) {
+	return l.logger.ReadLog(offset, length)
+}
+
+func (l *RateLimitLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return l.logger.ReadTailLog(offset, length)
+}
+
+func (l *RateLimitLogger) ReadTailLines(n int) ([]string, error) {
+	return l.logger.ReadTailLines(n)
+}
+
+func (l *RateLimitLogger) LineCount() (int64, error) {
+	return l.logger.LineCount()
+}
+
+func (l *RateLimitLogger) Stats() LoggerStats {
+	return l.logger.Stats()
+}
+
+func (l *RateLimitLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func (l *RateLimitLogger) ClearCurLogFile() error {
+	return l.logger.ClearCurLogFile()
+}
+
+func (l *RateLimitLogger) ClearAllLogFile() error {
+	return l.logger.ClearAllLogFile()
+}
+
+func (l *RateLimitLogger) ClearOldestBackup() (string, error) {
+	return l.logger.ClearOldestBackup()
+}
@@ -0,0 +1,43 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestRateLimitWriteLines(t *testing.T) {
+	mem := core.NewMemoryLogger()
+	clock := &steppedClock{now: time.Unix(0, 0)}
+	l, err := core.NewRateLimitLogger(mem, 2, time.Second, core.WithRateLimitClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := l.WriteLines([]string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 {
+		t.Errorf("n = %d, want 8", n)
+	}
+	if got, want := mem.String(), "a\nb\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := l.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+
+	//the summary of the lines dropped comes before the next line kept
+	clock.now = clock.now.Add(time.Second)
+	if _, err := l.WriteLines([]string{"e", "f", "g"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mem.String(), "a\nb\n…suppressed 2 messages\ne\nf\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := l.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,197 @@
+package core
+
+import (
+	"crypto/cipher"
+	"io"
+	"os"
+)
+
+// multiReadCloser reads a sequence of files one after the other
+type multiReadCloser struct {
+	io.Reader
+	files []io.Closer
+}
+
+func (m *multiReadCloser) Close() error {
+	var err error
+	for _, f := range m.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// RecentReader returns a reader over the last maxBytes bytes of the log,
+// starting in a rotated file if the current one is shorter than maxBytes.
+// The file sizes are taken when it is called, the caller must close it
+func (l *FileLogger) RecentReader(maxBytes int64) (io.ReadCloser, int64, error) {
+	l.rlock()
+	defer l.runlock()
+
+	return l.recentReader(maxBytes)
+}
+
+// the lock free part of RecentReader, it also returns the number of bytes
+// the reader will return
+func (l *FileLogger) recentReader(maxBytes int64) (io.ReadCloser, int64, error) {
+	if maxBytes < 0 {
+		return nil, 0, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	files, err := l.listLogFiles()
+	if err != nil {
+		return nil, 0, NewFaultWrap(FAILED, "FAILED", err)
+	}
+
+	m := &multiReadCloser{}
+	readers := make([]io.Reader, 0)
+	total := int64(0)
+	//walk from the newest file back until maxBytes are collected
+	for i := len(files) - 1; i >= 0 && total < maxBytes; i-- {
+		f, size, err := openRecent(l.fs, files[i].name, l.aead)
+		if os.IsNotExist(err) && !isCompressed(files[i].name) {
+			//compressed since it was listed
+			f, size, err = openRecent(l.fs, findCompressed(l.fs, files[i].name), l.aead)
+		}
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			m.Close()
+			return nil, 0, NewFaultWrap(FAILED, "FAILED", err)
+		}
+		offset := int64(0)
+		if total+size > maxBytes {
+			offset = size - (maxBytes - total)
+		}
+		m.files = append(m.files, f)
+		readers = append([]io.Reader{f.section(offset, size-offset)}, readers...)
+		total += size - offset
+	}
+	m.Reader = io.MultiReader(readers...)
+	return m, total, nil
+}
+
+// Snapshot returns the last maxBytes bytes of the log as one slice, spanning
+// the current and the rotated files, for example to attach the recent logs
+// to a crash report. At most maxBytes bytes are allocated for the data, the
+// compressed files being decompressed as a stream, except for an encrypted
+// log whose files are decrypted whole in memory like by every read
+func (l *FileLogger) Snapshot(maxBytes int64) ([]byte, error) {
+	l.rlock()
+	defer l.runlock()
+
+	r, total, err := l.recentReader(maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	b := make([]byte, total)
+	n, err := io.ReadFull(r, b)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	return b[:n], nil
+}
+
+// recentFile is a log file opened by recentReader
+type recentFile interface {
+	io.Closer
+	// return a reader of n bytes of the file from offset, decompressed
+	section(offset int64, n int64) io.Reader
+}
+
+// open a log file for recentReader and return its size, decompressed
+func openRecent(fs FS, fileName string, aead cipher.AEAD) (recentFile, int64, error) {
+	c, compressed := compressorFor(fileName)
+	//an encrypted file is decrypted whole
+	if !compressed || aead != nil {
+		f, size, err := openRange(fs, fileName, aead)
+		if err != nil {
+			return nil, 0, err
+		}
+		return rangeRecent{f}, size, nil
+	}
+
+	f, err := fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	zr, err := c.Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	size, err := io.Copy(io.Discard, zr)
+	zr.Close()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return &compressedRecent{f: f, c: c}, size, nil
+}
+
+// rangeRecent is a log file read at random like by ReadLog
+type rangeRecent struct {
+	rangeFile
+}
+
+func (r rangeRecent) section(offset int64, n int64) io.Reader {
+	return io.NewSectionReader(r, offset, n)
+}
+
+// compressedRecent is a compressed log file decompressed once to get its
+// size, then once more up to its section by the first read of it, so the
+// file is never held in memory
+type compressedRecent struct {
+	f      *os.File
+	c      Compressor
+	offset int64
+	n      int64
+	zr     io.ReadCloser
+	r      io.Reader
+}
+
+func (z *compressedRecent) section(offset int64, n int64) io.Reader {
+	z.offset = offset
+	z.n = n
+	return z
+}
+
+func (z *compressedRecent) Read(p []byte) (int, error) {
+	if z.r == nil {
+		if err := z.open(); err != nil {
+			return 0, err
+		}
+	}
+	return z.r.Read(p)
+}
+
+// decompress the file again from its start and skip up to the section
+func (z *compressedRecent) open() error {
+	if _, err := z.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if z.zr != nil {
+		z.zr.Close()
+		z.zr = nil
+	}
+	zr, err := z.c.Decompress(z.f)
+	if err != nil {
+		return err
+	}
+	z.zr = zr
+	if _, err := io.CopyN(io.Discard, zr, z.offset); err != nil {
+		return unexpectedEOF(err)
+	}
+	z.r = io.LimitReader(zr, z.n)
+	return nil
+}
+
+func (z *compressedRecent) Close() error {
+	if z.zr != nil {
+		z.zr.Close()
+	}
+	return z.f.Close()
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// the content of the log files of l, oldest first
+func logContent(t *testing.T, l *FileLogger) string {
+	t.Helper()
+	b, err := l.Snapshot(1 << 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSnapshot(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		l := newTestLogger(t, 30, 3, WithCompress(compress))
+		writeTestLines(t, l, 8, 10)
+		l.compressWG.Wait()
+		//two backups of 30 bytes and the current file
+		content := ""
+		for i := 0; i < 8; i++ {
+			content += fmt.Sprintf("%09d\n", i)
+		}
+		if got := logContent(t, l); got != content {
+			t.Fatalf("compress %t: log %q, want %q", compress, got, content)
+		}
+
+		for _, maxBytes := range []int64{0, 1, 20, 21, 30, 50, 51, 79, 80, 81, 1000} {
+			want := content
+			if maxBytes < int64(len(content)) {
+				want = content[int64(len(content))-maxBytes:]
+			}
+			b, err := l.Snapshot(maxBytes)
+			if err != nil || string(b) != want {
+				t.Errorf("compress %t: Snapshot(%d) = %q, %v, want %q", compress, maxBytes, b, err, want)
+			}
+		}
+		if _, err := l.Snapshot(-1); faultCode(err) != BAD_ARGUMENTS {
+			t.Errorf("compress %t: Snapshot(-1) = %v, want BAD_ARGUMENTS", compress, err)
+		}
+	}
+}
+
+func TestSnapshotCompressedBackupMemory(t *testing.T) {
+	l := newTestLogger(t, 8<<20, 2, WithCompress(true))
+	writeTestLines(t, l, 1024, 8<<10)
+	l.compressWG.Wait()
+	writeTestLines(t, l, 1, 100)
+	if _, err := os.Stat(l.getLogFileName(0) + ".gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b, err := l.Snapshot(1 << 10)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 1<<10 || !bytes.HasSuffix(b[:len(b)-100], []byte("1023\n")) {
+		t.Fatalf("Snapshot returned %d bytes", len(b))
+	}
+	//the decompressors and the copy buffers, not the 8MB backup
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+		t.Errorf("Snapshot(1KB) of an 8MB compressed backup allocated %d bytes", alloc)
+	}
+}
+
+// an FS compressing a backup when it is opened, like a compression ending
+// between the listing of the files and their reading
+type compressingFS struct {
+	osFS
+	name string
+}
+
+func (fs *compressingFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if name == fs.name && flag == os.O_RDONLY {
+		fs.name = ""
+		if err := gzipFile(name); err != nil {
+			return nil, err
+		}
+	}
+	return fs.osFS.OpenFile(name, flag, perm)
+}
+
+func TestSnapshotCompressedWhileListed(t *testing.T) {
+	fs := &compressingFS{}
+	l := newTestLogger(t, 20, 3, WithFS(fs))
+	writeTestLines(t, l, 3, 10)
+	fs.name = l.getLogFileName(0)
+	b, err := l.Snapshot(100)
+	if want := "000000000\n000000001\n000000002\n"; err != nil || string(b) != want {
+		t.Errorf("Snapshot = %q, %v, want %q", b, err, want)
+	}
+	if fs.name != "" {
+		t.Error("the backup was not opened")
+	}
+}
+
+// replace a file by its gzip version
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(name + ".gz")
+	if err != nil {
+		return err
+	}
+	if err := (GzipCompressor{}).Compress(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
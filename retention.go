@@ -0,0 +1,107 @@
+package core
+
+import (
+	"os"
+)
+
+// PlanRetention returns the log files whose content the next rotation would
+// discard under the current retention policy, without removing anything.
+// It lets an operator check a policy before it deletes real logs
+func (l *FileLogger) PlanRetention() ([]string, error) {
+	l.rlock()
+	defer l.runlock()
+
+	return l.retentionPlan()
+}
+
+// return the files the retention policy would discard on the next rotation,
+// the caller must hold the lock
+func (l *FileLogger) retentionPlan() ([]string, error) {
+	plan := make([]string, 0)
+	if l.strategy != RotateTimestamp {
+		// the ring reuses the next file and the shift drops the oldest one,
+		// so its previous content is lost
+		next := l.getLogFileName(l.nextRotate())
+		if l.strategy == RotateShift {
+			next = l.getLogFileName(l.ringSize())
+		}
+		names := []string{next}
+		for _, ext := range compressExtensions() {
+			names = append(names, next+ext)
+		}
+		for _, name := range names {
+			if _, err := l.fs.Stat(name); err == nil {
+				plan = append(plan, name)
+			} else if !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+	removals, err := l.removals(1)
+	if err != nil {
+		return nil, err
+	}
+	return append(plan, removals...), nil
+}
+
+// retentionRemovals returns the backups the retention policy removes after
+// a rotation. The current file is never part of it. The ring alone never
+// removes a file since it reuses them, but WithMaxTotalSize and WithMaxAge
+// apply to all the strategies. The caller must hold the lock
+func (l *FileLogger) retentionRemovals() ([]string, error) {
+	return l.removals(0)
+}
+
+// return the backups to remove once extra more files are created
+func (l *FileLogger) removals(extra int) ([]string, error) {
+	removals := make([]string, 0)
+	if l.strategy != RotateTimestamp && l.maxTotalSize <= 0 && l.maxAge <= 0 {
+		return removals, nil
+	}
+	files, err := l.listLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	//only the timestamped files are not limited by their names
+	excess := 0
+	if l.strategy == RotateTimestamp {
+		excess = len(files) + extra - l.ringSize()
+	}
+	total := int64(0)
+	for _, f := range files {
+		total += f.info.Size()
+	}
+	cutoff := l.clock.Now().Add(-l.maxAge)
+	//the files are in chronological order, oldest first
+	for _, f := range files {
+		if f.name == l.currentLogFile() {
+			continue
+		}
+		over := excess > 0 || (l.maxTotalSize > 0 && total > l.maxTotalSize)
+		expired := l.maxAge > 0 && f.info.ModTime().Before(cutoff)
+		if over || expired {
+			removals = append(removals, f.name)
+			total -= f.info.Size()
+			excess--
+		}
+	}
+	return removals, nil
+}
+
+// applyRetention removes the backups returned by retentionRemovals, a file
+// already gone is not an error. The caller must hold the lock
+func (l *FileLogger) applyRetention() error {
+	removals, err := l.retentionRemovals()
+	if err != nil {
+		return err
+	}
+	for _, fileName := range removals {
+		if err := l.fs.Remove(fileName); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if l.checksum {
+			l.discardChecksum(trimCompressed(fileName))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"bytes"
+	"io"
+)
+
+const reverseChunkSize = 32 << 10
+
+// ReadLinesReverse returns up to maxLines lines of the current log file,
+// newest first and without their newline, read backward from startFromEnd
+// bytes before the end of file. The file is read in chunks, so only the
+// lines returned are held in memory. A line cut by startFromEnd is left out,
+// while at the end of file a last line not ended by a newline is returned
+// like ReadTailLines does
+func (l *FileLogger) ReadLinesReverse(startFromEnd int64, maxLines int) ([]string, error) {
+	if startFromEnd < 0 || maxLines < 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l.rlock()
+	defer l.runlock()
+
+	f, fileLen, err := l.openCurrentRange()
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	defer f.Close()
+
+	return ReadLinesReverseAt(f, fileLen, startFromEnd, maxLines)
+}
+
+// ReadLinesReverseAt reads r, whose size is fileLen, with the
+// ReadLinesReverse rules
+func ReadLinesReverseAt(r io.ReaderAt, fileLen int64, startFromEnd int64, maxLines int) ([]string, error) {
+	if startFromEnd < 0 || maxLines < 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	if maxLines == 0 || startFromEnd >= fileLen {
+		return nil, nil
+	}
+
+	end := fileLen - startFromEnd
+	last := make([]byte, 1)
+	if _, err := r.ReadAt(last, end-1); err != nil && err != io.EOF {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	//a newline ending the read ends the newest line, else the bytes after
+	//the last newline are a line cut by startFromEnd, except at end of file
+	cut := false
+	if last[0] == '\n' {
+		end--
+	} else {
+		cut = startFromEnd > 0
+	}
+
+	var lines []string
+	//the start of the line being read, found in the chunks read so far
+	var carry []byte
+	pos := end
+	for pos > 0 {
+		size := int64(reverseChunkSize)
+		if size > pos {
+			size = pos
+		}
+		pos -= size
+		data := make([]byte, size, size+int64(len(carry)))
+		if _, err := r.ReadAt(data, pos); err != nil && err != io.EOF {
+			return nil, NewFaultWrap(FAILED, "FAILED", err)
+		}
+		data = append(data, carry...)
+
+		//every newline ends the line before the part of data after it
+		for i := bytes.LastIndexByte(data, '\n'); i >= 0; i = bytes.LastIndexByte(data, '\n') {
+			if cut {
+				cut = false
+			} else {
+				lines = append(lines, string(data[i+1:]))
+				if len(lines) == maxLines {
+					return lines, nil
+				}
+			}
+			data = data[:i]
+		}
+		carry = data
+	}
+	//the oldest line starts the file
+	if !cut {
+		lines = append(lines, string(carry))
+	}
+	return lines, nil
+}
@@ -0,0 +1,84 @@
+package core_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// reverseLines is what ReadLinesReverseAt should return for content
+func reverseLines(content string, startFromEnd int64, maxLines int) []string {
+	if maxLines == 0 || startFromEnd >= int64(len(content)) {
+		return nil
+	}
+	s := content[:int64(len(content))-startFromEnd]
+	if !strings.HasSuffix(s, "\n") && startFromEnd > 0 {
+		i := strings.LastIndexByte(s, '\n')
+		if i < 0 {
+			return nil
+		}
+		s = s[:i+1]
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	var reversed []string
+	for i := len(lines) - 1; i >= 0 && len(reversed) < maxLines; i-- {
+		reversed = append(reversed, lines[i])
+	}
+	return reversed
+}
+
+func TestReadLinesReverseLargeFile(t *testing.T) {
+	//lines from empty to a few 32KB read chunks long, so lines both end
+	//inside a chunk and span several of them
+	var b strings.Builder
+	for i := 0; b.Len() < 3<<20; i++ {
+		n := (i * 7919) % 100000
+		if i%10 == 0 {
+			n = 0
+		}
+		b.WriteString(strings.Repeat(string(rune('a'+i%26)), n))
+		b.WriteByte('\n')
+	}
+	b.WriteString("unterminated")
+	content := b.String()
+
+	name := filepath.Join(t.TempDir(), "app.log")
+	l := newLogger(t, name)
+	write(t, l, content)
+
+	lines, err := l.ReadLinesReverse(0, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := reverseLines(content, 0, 1<<20); !reflect.DeepEqual(lines, want) {
+		t.Fatalf("ReadLinesReverse(0) returned %d lines, want %d", len(lines), len(want))
+	}
+	if lines[0] != "unterminated" {
+		t.Fatalf("newest line = %.20q, want %q", lines[0], "unterminated")
+	}
+
+	r := bytes.NewReader([]byte(content))
+	size := int64(len(content))
+	for _, startFromEnd := range []int64{0, 1, 12, 13, 14, 32 << 10, 32<<10 + 1, 1 << 20, size - 1, size, size + 1} {
+		for _, maxLines := range []int{0, 1, 2, 10, 1 << 20} {
+			got, err := core.ReadLinesReverseAt(r, size, startFromEnd, maxLines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := reverseLines(content, startFromEnd, maxLines); !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadLinesReverseAt(%d, %d) returned %d lines, want %d", startFromEnd, maxLines, len(got), len(want))
+			}
+		}
+	}
+
+	if _, err := l.ReadLinesReverse(-1, 1); err == nil {
+		t.Error("ReadLinesReverse(-1, 1) did not fail")
+	}
+	if _, err := l.ReadLinesReverse(0, -1); err == nil {
+		t.Error("ReadLinesReverse(0, -1) did not fail")
+	}
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+)
+
+// RotationStrategy is the way a FileLogger names and recycles its files
+type RotationStrategy int
+
+const (
+	// RotateRing cycles through name.0 to name.<backups-1>, truncating the
+	// oldest file to reuse it. It is the default
+	RotateRing RotationStrategy = iota
+	// RotateTimestamp names every file name.<UTC creation time> and removes
+	// the oldest ones by the time in their name to keep at most backups
+	// files, the current one included
+	RotateTimestamp
+	// RotateShift writes to name and renames name.N to name.N+1 on each
+	// rotation, then name to name.1, like logrotate. The oldest backup,
+	// name.<backups>, is removed, so name.1 is always the most recent one
+	RotateShift
+)
+
+// WithRotationStrategy sets how the log files are named and recycled
+func WithRotationStrategy(strategy RotationStrategy) Option {
+	return func(l *FileLogger) {
+		l.strategy = strategy
+	}
+}
+
+// shouldRotate tells if the current log file is complete and the next
+// write must go to a new file. It only looks at the logger state and never
+// touches the disk. A pinned file is never rotated. The caller must hold the
+// lock
+func (l *FileLogger) shouldRotate() bool {
+	if l.pins > 0 || l.backups == 0 {
+		return false
+	}
+	if l.full() {
+		return true
+	}
+	return l.forceRotateAfter > 0 && l.fileWrites >= l.forceRotateAfter
+}
+
+// full tells if the current file reached the maximum size. A zero maxSize
+// never rotates by size, and zero backups never rotates at all: the single
+// file grows without bound
+func (l *FileLogger) full() bool {
+	return l.backups > 0 && l.maxSize > 0 && l.fileSize >= l.maxSize
+}
+
+// expired tells if the current file is older than the rotation interval. An
+// empty file never expires, so an idle logger does not create empty files.
+// The caller must hold the lock
+func (l *FileLogger) expired() bool {
+	if l.rotateInterval <= 0 || l.fileSize == 0 || l.pins > 0 || l.backups == 0 {
+		return false
+	}
+	return !l.clock.Now().Before(l.fileCreated.Add(l.rotateInterval))
+}
+
+// doRotate finishes the current log file, moves to the next one of the ring
+// and truncates it, then applies the retention policy. If the new file can't
+// be opened the logger is left without a file and the error is returned,
+// a retention error leaves the logger writing to the new file. The caller
+// must hold the lock
+func (l *FileLogger) doRotate() error {
+	l.writeFooter()
+	//the outgoing file is on disk before it is moved or left behind
+	if l.file != nil {
+		if err := l.sync(); err != nil {
+			l.handleError(err)
+		}
+	}
+	oldFile := l.currentLogFile()
+	if l.strategy == RotateShift {
+		if err := l.shiftLogFiles(); err != nil {
+			return err
+		}
+		oldFile = l.getLogFileName(1)
+	} else {
+		l.nextLogFile()
+	}
+	if l.compress || l.checksum {
+		//the ring drops the previous content of the reused file
+		l.discardCompressed(l.currentLogFile())
+	}
+	oldSize := l.fileSize
+	if err := l.openFile(true); err != nil {
+		return err
+	}
+	l.rotations++
+	if l.onRotate != nil {
+		l.rotatedOut = append(l.rotatedOut, oldFile)
+	}
+	l.publishRotation(oldFile, oldSize)
+	//hashed first, the compression removes the file
+	if l.checksum {
+		l.checksumLater(oldFile)
+	}
+	if l.compress {
+		l.compressLater(oldFile)
+	}
+	if len(l.postRotate) > 0 {
+		l.postRotateLater(oldFile)
+	}
+	return l.applyRetention()
+}
+
+// call the OnRotate callback with the files rotated out so far, in order.
+// It must be called without the lock, the callbacks are serialized by
+// notifyLock so they never run concurrently
+func (l *FileLogger) notifyRotated() {
+	if l.onRotate == nil {
+		return
+	}
+	l.notifyLock.Lock()
+	defer l.notifyLock.Unlock()
+
+	l.locker.Lock()
+	files := l.rotatedOut
+	l.rotatedOut = nil
+	l.locker.Unlock()
+	for _, oldFile := range files {
+		l.callOnRotate(oldFile)
+	}
+}
+
+// call the OnRotate callback, a panic goes to the error handler
+func (l *FileLogger) callOnRotate(oldFile string) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.locker.Lock()
+			l.handleError(fmt.Errorf("OnRotate callback panicked: %v", r))
+			l.locker.Unlock()
+		}
+	}()
+	l.onRotate(oldFile)
+}
@@ -0,0 +1,373 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func newLogger(t *testing.T, name string, opts ...core.Option) *core.FileLogger {
+	t.Helper()
+	l, err := core.NewFileLoggerWithOptions(name, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func write(t *testing.T, l *core.FileLogger, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := l.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestShouldRotate(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []core.Option
+		size   int64
+		writes int
+		pinned bool
+		want   bool
+	}{
+		{"below the maximum size", nil, 99, 1, false, false},
+		{"at the maximum size", nil, 100, 1, false, true},
+		{"past the maximum size", nil, 150, 1, false, true},
+		{"pinned", nil, 150, 1, true, false},
+		{"no maximum size", []core.Option{core.WithMaxSize(0)}, 1000, 1, false, false},
+		{"no backups", []core.Option{core.WithBackups(0)}, 1000, 1, false, false},
+		{"below the write count", []core.Option{core.WithForceRotateAfter(3)}, 10, 2, false, false},
+		{"at the write count", []core.Option{core.WithForceRotateAfter(3)}, 10, 3, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]core.Option{core.WithMaxSize(100), core.WithBackups(3)}, tt.opts...)
+			l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), opts...)
+			l.SetFileState(tt.size, tt.writes)
+			if tt.pinned {
+				l.PinCurrent()
+			}
+			if got := l.ShouldRotate(); got != tt.want {
+				t.Fatalf("ShouldRotate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoRotate(t *testing.T) {
+	tests := []struct {
+		strategy core.RotationStrategy
+		// the current file after each rotation, relative to the name
+		currents []string
+	}{
+		{core.RotateRing, []string{".1", ".2", ".0", ".1"}},
+		{core.RotateShift, []string{"", "", "", ""}},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(t.TempDir(), "test.log")
+		l := newLogger(t, name, core.WithMaxSize(0), core.WithBackups(3), core.WithRotationStrategy(tt.strategy))
+		for i, current := range tt.currents {
+			write(t, l, strings.Repeat("x", i+1)+"\n")
+			if err := l.DoRotate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := l.GetCurrentLogFile(); got != name+current {
+				t.Fatalf("strategy %d rotation %d: current file %s", tt.strategy, i, got)
+			}
+			if s, _ := l.ReadLog(0, 0); s != "" {
+				t.Fatalf("strategy %d rotation %d: new file holds %q", tt.strategy, i, s)
+			}
+		}
+		if n := l.Stats().RotationCount; n != int64(len(tt.currents)) {
+			t.Fatalf("strategy %d: %d rotations counted", tt.strategy, n)
+		}
+		//the last backup holds the last record
+		if s, _ := l.ReadOlderLog(1, 0, 0); s != "xxxx\n" {
+			t.Fatalf("strategy %d: last backup holds %q", tt.strategy, s)
+		}
+	}
+}
+
+func TestRetention(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		opts []core.Option
+		// the ages of the backups, oldest first, each holding 10 bytes
+		ages []time.Duration
+		// the indexes in ages of the backups removed
+		removed []int
+	}{
+		{"no policy", nil, []time.Duration{3 * time.Hour, 2 * time.Hour, time.Hour}, nil},
+		{"total size", []core.Option{core.WithMaxTotalSize(15)},
+			[]time.Duration{3 * time.Hour, 2 * time.Hour, time.Hour}, []int{0, 1}},
+		{"age", []core.Option{core.WithMaxAge(90 * time.Minute)},
+			[]time.Duration{3 * time.Hour, 2 * time.Hour, time.Hour}, []int{0, 1}},
+		{"age and total size", []core.Option{core.WithMaxAge(150 * time.Minute), core.WithMaxTotalSize(5)},
+			[]time.Duration{3 * time.Hour, 2 * time.Hour, time.Hour}, []int{0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "test.log")
+			//the ring files before the current one, name.4, are the backups
+			var files []string
+			for i, age := range tt.ages {
+				file := name + "." + string(rune('1'+i))
+				os.WriteFile(file, []byte("123456789\n"), 0644)
+				stamp := now.Add(-age)
+				os.Chtimes(file, stamp, stamp)
+				files = append(files, file)
+			}
+			os.WriteFile(name+".4", nil, 0644)
+
+			opts := append([]core.Option{core.WithMaxSize(100), core.WithBackups(5), core.WithClock(fixedClock{now})}, tt.opts...)
+			l := newLogger(t, name, opts...)
+			if l.GetCurrentLogFile() != name+".4" {
+				t.Fatalf("current file is %s", l.GetCurrentLogFile())
+			}
+			want := make([]string, 0)
+			for _, i := range tt.removed {
+				want = append(want, files[i])
+			}
+			got, err := l.RetentionRemovals()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("RetentionRemovals() = %v, want %v", got, want)
+			}
+			if err := l.ApplyRetention(); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range want {
+				if _, err := os.Stat(file); !os.IsNotExist(err) {
+					t.Fatalf("%s not removed", file)
+				}
+			}
+			if _, err := os.Stat(l.GetCurrentLogFile()); err != nil {
+				t.Fatalf("current file removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetentionTimestampCount(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &steppedClock{now: now}
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithMaxSize(10), core.WithBackups(3),
+		core.WithRotationStrategy(core.RotateTimestamp), core.WithClock(clock))
+	for i := 0; i < 6; i++ {
+		clock.step()
+		write(t, l, "123456789\n")
+	}
+	files, err := l.GetLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("%d files kept, want 3", len(files))
+	}
+	if removals, _ := l.RetentionRemovals(); len(removals) != 0 {
+		t.Fatalf("files left to remove: %v", removals)
+	}
+}
+
+// a clock moved forward by step
+type steppedClock struct {
+	now time.Time
+}
+
+func (c *steppedClock) Now() time.Time {
+	return c.now
+}
+
+func (c *steppedClock) step() {
+	c.now = c.now.Add(time.Second)
+}
+
+func TestRotateAtSizeBoundary(t *testing.T) {
+	tests := []struct {
+		name string
+		// the records written, the last one decides the rotation
+		records []string
+		rotated bool
+	}{
+		{"one byte below", []string{"12345678\n"}, false},
+		{"exactly at the size", []string{"123456789\n"}, true},
+		{"reaching the size in two writes", []string{"12345\n", "678\n"}, true},
+		{"one byte below in two writes", []string{"12345\n", "67\n"}, false},
+		{"past the size", []string{"12345678901234\n"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithMaxSize(10), core.WithBackups(3))
+			first := l.GetCurrentLogFile()
+			write(t, l, tt.records...)
+			if rotated := l.GetCurrentLogFile() != first; rotated != tt.rotated {
+				t.Fatalf("rotated %v, want %v", rotated, tt.rotated)
+			}
+			//the whole records stay in the file they were written to
+			content := readFile(t, first)
+			if want := strings.Join(tt.records, ""); content != want {
+				t.Errorf("first file %q, want %q", content, want)
+			}
+			if tt.rotated {
+				if got := readFile(t, l.GetCurrentLogFile()); got != "" {
+					t.Errorf("new file %q, want it empty", got)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateAtSizeBoundaryAfterRestart(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithMaxSize(10), core.WithBackups(3))
+	write(t, l, "12345\n")
+	first := l.GetCurrentLogFile()
+	l.Close()
+
+	//the size of the file continued counts toward the maximum
+	l = newLogger(t, name, core.WithMaxSize(10), core.WithBackups(3))
+	if l.GetCurrentLogFile() != first {
+		t.Fatalf("current file %s, want %s continued", l.GetCurrentLogFile(), first)
+	}
+	write(t, l, "67\n")
+	if l.GetCurrentLogFile() != first {
+		t.Fatal("rotated one byte below the maximum size")
+	}
+	write(t, l, "9")
+	if l.GetCurrentLogFile() == first {
+		t.Fatal("not rotated at the maximum size")
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotationStrategies(t *testing.T) {
+	tests := []struct {
+		strategy core.RotationStrategy
+		// the files on disk, relative to the name
+		files []string
+		// the backups, newest first
+		backups []string
+	}{
+		{core.RotateRing, []string{".0", ".1", ".2"}, []string{"ffffffff\n", "eeeeeeee\n"}},
+		{core.RotateShift, []string{"", ".1", ".2", ".3"}, []string{"ffffffff\n", "eeeeeeee\n", "dddddddd\n"}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		name := filepath.Join(dir, "test.log")
+		l := newLogger(t, name, core.WithMaxSize(9), core.WithBackups(3), core.WithRotationStrategy(tt.strategy))
+		for _, c := range "abcdef" {
+			write(t, l, strings.Repeat(string(c), 8)+"\n")
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			files = append(files, strings.TrimPrefix(entry.Name(), "test.log"))
+		}
+		if !reflect.DeepEqual(files, tt.files) {
+			t.Errorf("strategy %d: files %v, want %v", tt.strategy, files, tt.files)
+		}
+		for i, want := range tt.backups {
+			if got, err := l.ReadOlderLog(i+1, 0, 0); err != nil || got != want {
+				t.Errorf("strategy %d: backup %d holds %q, %v, want %q", tt.strategy, i+1, got, err, want)
+			}
+		}
+		if _, err := l.ReadOlderLog(len(tt.backups)+1, 0, 0); err == nil {
+			t.Errorf("strategy %d: more than %d backups", tt.strategy, len(tt.backups))
+		}
+		if got, _ := l.ReadLog(0, 0); got != "" {
+			t.Errorf("strategy %d: current file holds %q", tt.strategy, got)
+		}
+	}
+}
+
+func TestNoBackupsNeverRotates(t *testing.T) {
+	dir := t.TempDir()
+	l := newLogger(t, filepath.Join(dir, "test.log"), core.WithMaxSize(10), core.WithBackups(0),
+		core.WithForceRotateAfter(2), core.WithRotateInterval(time.Nanosecond))
+	first := l.GetCurrentLogFile()
+	for i := 0; i < 5; i++ {
+		write(t, l, "123456789\n")
+	}
+	if l.GetCurrentLogFile() != first {
+		t.Fatalf("rotated to %s", l.GetCurrentLogFile())
+	}
+	if got, _ := l.ReadLog(0, 0); got != strings.Repeat("123456789\n", 5) {
+		t.Errorf("ReadLog = %q, want every record in the single file", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files on disk, want 1", len(entries))
+	}
+	if backups, err := l.ListBackups(); err != nil || len(backups) != 0 {
+		t.Errorf("ListBackups = %v, %v, want none", backups, err)
+	}
+	if _, err := l.ClearOldestBackup(); err != core.ErrNoBackup {
+		t.Errorf("ClearOldestBackup = %v, want ErrNoBackup", err)
+	}
+	if n := l.Stats().RotationCount; n != 0 {
+		t.Errorf("%d rotations counted", n)
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	var l *core.FileLogger
+	var rotated, contents []string
+	l = newLogger(t, name, core.WithMaxSize(5), core.WithBackups(3),
+		core.WithOnRotate(func(oldFile string) {
+			rotated = append(rotated, oldFile)
+			//the lock is released, the callback can use the logger
+			s, _ := l.ReadOlderLog(1, 0, 0)
+			contents = append(contents, s)
+		}))
+	write(t, l, "aaaa\n", "bbbb\n", "cc\n")
+	if want := []string{name + ".0", name + ".1"}; !reflect.DeepEqual(rotated, want) {
+		t.Errorf("callback got %v, want %v", rotated, want)
+	}
+	if want := []string{"aaaa\n", "bbbb\n"}; !reflect.DeepEqual(contents, want) {
+		t.Errorf("callback read %v, want %v", contents, want)
+	}
+}
+
+func TestOnRotatePanic(t *testing.T) {
+	var handled []error
+	l := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithMaxSize(5), core.WithBackups(3),
+		core.WithOnRotate(func(string) { panic("callback") }),
+		core.WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	write(t, l, "aaaa\n", "bbbb\n")
+	if len(handled) != 2 || !strings.Contains(handled[0].Error(), "callback") {
+		t.Errorf("handled %v, want the two panics", handled)
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"time"
+)
+
+// number of rotation events kept for a slow subscriber before new ones are
+// dropped
+const rotationEventsBuffer = 16
+
+// RotationEvent describes a rotation published on RotationEvents
+type RotationEvent struct {
+	// the file rotated out, under its name after the rotation
+	OldFile string
+	// the file written from the rotation on
+	NewFile string
+	// when the rotation happened, from the logger clock
+	Time time.Time
+	// the size of the file rotated out
+	Size int64
+}
+
+// RotationEvents returns a channel receiving an event for each rotation,
+// for the observers which must not slow the writes: an event finding the
+// channel full is dropped. Every call returns the same channel, created by
+// the first one, and Close closes it. It works alongside WithOnRotate
+func (l *FileLogger) RotationEvents() <-chan RotationEvent {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if l.rotationEvents == nil {
+		l.rotationEvents = make(chan RotationEvent, rotationEventsBuffer)
+		if l.closed {
+			close(l.rotationEvents)
+		}
+	}
+	return l.rotationEvents
+}
+
+// publish a rotation to RotationEvents without waiting for a receiver. The
+// caller must hold the lock
+func (l *FileLogger) publishRotation(oldFile string, size int64) {
+	if l.rotationEvents == nil || l.closed {
+		return
+	}
+	select {
+	case l.rotationEvents <- RotationEvent{OldFile: oldFile,
+		NewFile: l.currentLogFile(),
+		Time:    l.clock.Now(),
+		Size:    size}:
+	default:
+	}
+}
@@ -0,0 +1,97 @@
+package core_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestRotationEvents(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		strategy core.RotationStrategy
+		// the old and new files of the first two rotations, relative to the name
+		files [][2]string
+	}{
+		{core.RotateRing, [][2]string{{".0", ".1"}, {".1", ".2"}}},
+		{core.RotateShift, [][2]string{{".1", ""}, {".1", ""}}},
+	}
+	for _, tt := range tests {
+		name := filepath.Join(t.TempDir(), "test.log")
+		l := newLogger(t, name, core.WithMaxSize(9), core.WithBackups(3),
+			core.WithRotationStrategy(tt.strategy), core.WithClock(fixedClock{now}))
+		events := l.RotationEvents()
+		if l.RotationEvents() != events {
+			t.Errorf("strategy %d: RotationEvents returned another channel", tt.strategy)
+		}
+
+		write(t, l, "abc\n", "defghijk\n", "lmnopqrstu\n")
+		for i, files := range tt.files {
+			want := core.RotationEvent{OldFile: name + files[0], NewFile: name + files[1], Time: now, Size: 13}
+			if i == 1 {
+				want.Size = 11
+			}
+			select {
+			case ev := <-events:
+				if ev != want {
+					t.Errorf("strategy %d: event %d = %+v, want %+v", tt.strategy, i, ev, want)
+				}
+			default:
+				t.Fatalf("strategy %d: no event for rotation %d", tt.strategy, i)
+			}
+		}
+		select {
+		case ev := <-events:
+			t.Errorf("strategy %d: unexpected event %+v", tt.strategy, ev)
+		default:
+		}
+	}
+}
+
+func TestRotationEventsFullChannel(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithMaxSize(9), core.WithBackups(3))
+	events := l.RotationEvents()
+
+	//the writes go on while nobody receives, the events past the buffer
+	//are dropped
+	for i := 0; i < 40; i++ {
+		write(t, l, strings.Repeat("x", 9))
+	}
+	if n := len(events); n != cap(events) || n == 0 {
+		t.Errorf("%d events buffered, want a full buffer of %d", n, cap(events))
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for range events {
+		n++
+	}
+	if n != cap(events) {
+		t.Errorf("received %d events before the channel closed, want %d", n, cap(events))
+	}
+	if _, ok := <-l.RotationEvents(); ok {
+		t.Error("RotationEvents after Close returned an open channel")
+	}
+}
+
+func TestRotationEventsAfterClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-l.RotationEvents():
+		if ok {
+			t.Error("received an event from a closed logger")
+		}
+	case <-time.After(time.Second):
+		t.Error("RotationEvents of a closed logger is not closed")
+	}
+}
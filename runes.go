@@ -0,0 +1,61 @@
+package core
+
+import (
+	"unicode/utf8"
+)
+
+// ReadLogRunes reads the current log file like ReadLog, but drops the
+// partial UTF-8 characters the window cuts at both ends. It also returns the
+// offset in the file the text starts at, so a caller reading sequential
+// windows can carry on from that offset plus len of the text and never sees
+// a character split in two. ReadLog keeps the byte exact behavior
+func (l *FileLogger) ReadLogRunes(offset int64, length int64) (string, int64, error) {
+	if err := checkReadArgs(offset, length); err != nil {
+		return "", offset, err
+	}
+	l.rlock()
+	defer l.runlock()
+
+	f, fileLen, err := l.openCurrentRange()
+	if err != nil {
+		return "", offset, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	defer f.Close()
+
+	b, err := ReadAtRange(f, fileLen, offset, length)
+	if err != nil {
+		return "", offset, err
+	}
+
+	//a negative offset counts from the end of file
+	if offset < 0 {
+		offset += fileLen
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	b, skipped := trimPartialRunes(b)
+	return string(b), offset + int64(skipped), nil
+}
+
+// trim the bytes of the characters cut at both ends of b, return the rest
+// and how many bytes were dropped at the front
+func trimPartialRunes(b []byte) ([]byte, int) {
+	//continuation bytes at the front belong to a character started before b
+	skipped := 0
+	for skipped < len(b) && skipped < utf8.UTFMax-1 && !utf8.RuneStart(b[skipped]) {
+		skipped++
+	}
+	b = b[skipped:]
+
+	//the last character started may miss its tail
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				b = b[:i]
+			}
+			break
+		}
+	}
+	return b, skipped
+}
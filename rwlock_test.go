@@ -0,0 +1,157 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadsShareTheLock(t *testing.T) {
+	l := newTestLogger(t, 0, 3)
+	l.Write([]byte("line\n"))
+
+	//a read taken by another reader does not stop ReadLog
+	l.rlock()
+	done := make(chan string)
+	go func() {
+		s, _ := l.ReadLog(0, 0)
+		done <- s
+	}()
+	select {
+	case s := <-done:
+		if s != "line\n" {
+			t.Fatalf("ReadLog returned %q", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadLog blocked by another reader")
+	}
+
+	//but a write waits for the readers
+	written := make(chan struct{})
+	go func() {
+		l.Write([]byte("more\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write did not wait for the reader")
+	case <-time.After(50 * time.Millisecond):
+	}
+	l.runlock()
+	<-written
+}
+
+func TestReadsWithPlainLocker(t *testing.T) {
+	l := newTestLogger(t, 0, 3, WithLocker(&sync.Mutex{}))
+	l.Write([]byte("line\n"))
+	if s, err := l.ReadLog(0, 0); err != nil || s != "line\n" {
+		t.Fatalf("ReadLog returned %q %v", s, err)
+	}
+}
+
+func TestConcurrentReadersRace(t *testing.T) {
+	l := newTestLogger(t, 1000, 4, WithChecksum(true))
+	line := strings.Repeat("x", 99) + "\n"
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 300; j++ {
+				if _, err := l.Write([]byte(line)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	readers := []func() error{
+		func() error { _, err := l.ReadLog(0, 0); return err },
+		func() error { _, err := l.ReadLogBytes(-10, 0); return err },
+		func() error { _, _, _, err := l.ReadLogPage(0, 100); return err },
+		func() error { _, _, _, err := l.ReadTailLog(0, 100); return err },
+		func() error { _, err := l.ReadTailLines(2); return err },
+		func() error { _, _, err := l.ReadLogRunes(0, 50); return err },
+		func() error { _, err := l.ReadLinesReverse(0, 3); return err },
+		func() error { _, err := l.ReadCombinedLog(-500, 0); return err },
+		func() error { _, err := l.GetLogFiles(); return err },
+		func() error { _, err := l.ListBackups(); return err },
+		func() error { _, err := l.PlanRetention(); return err },
+		func() error { _, err := l.SearchAll("x+", 1); return err },
+		func() error { _, err := l.Snapshot(1000); return err },
+		func() error { _ = l.Stats(); return nil },
+		func() error {
+			l.GetCurrentLogFile()
+			l.GetPrevLogFile()
+			l.GetNextLogFile()
+			l.CurrentRotation()
+			return nil
+		},
+	}
+	for _, read := range readers {
+		wg.Add(1)
+		go func(read func() error) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := read(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(read)
+	}
+	wg.Wait()
+}
+
+func TestGettersDuringRotationRace(t *testing.T) {
+	const maxSize = 50
+	l := newTestLogger(t, maxSize, 3)
+	names := map[string]bool{l.getLogFileName(0): true, l.getLogFileName(1): true, l.getLogFileName(2): true}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(stop)
+		for i := 0; i < 2000; i++ {
+			if _, err := l.Write([]byte("123456789\n")); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				//a full file is rotated before the lock is released, so a
+				//reader never sees it
+				if size := l.Stats().CurrentFileSize; size >= maxSize {
+					t.Errorf("current file size %d", size)
+				}
+				if s, _ := l.ReadLog(0, 0); len(s) >= maxSize {
+					t.Errorf("current file holds %d bytes", len(s))
+				}
+				for _, name := range []string{l.GetCurrentLogFile(), l.GetPrevLogFile(), l.GetNextLogFile()} {
+					if !names[name] {
+						t.Errorf("file %q is not in the ring", name)
+					}
+				}
+				if n := l.CurrentRotation(); n < 0 || n > 2 {
+					t.Errorf("rotation %d", n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := l.Stats().RotationCount; n != 400 {
+		t.Errorf("%d rotations, want 400", n)
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+)
+
+// the longest line the searches match, a longer one is cut
+const maxSearchLine = 1 << 20
+
+// Search returns the lines of the current log file matching the regular
+// expression pattern, in order and without their newline, at most max of
+// them unless max is 0. The file is read line by line, without holding the
+// lock, and a line over 1MB is matched and returned cut to its first 1MB.
+// An invalid pattern returns a BAD_ARGUMENTS Fault
+func (l *FileLogger) Search(pattern string, max int) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil || max < 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	r, err := l.LogReader(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	matches := make([]string, 0)
+	return searchReader(r, re, max, matches)
+}
+
+// SearchAll is like Search over all the log files in chronological order,
+// the backups first and the current file last. Compressed backups are
+// decompressed, and a backup removed since the listing is skipped
+func (l *FileLogger) SearchAll(pattern string, max int) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil || max < 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	l.rlock()
+	files, err := l.listLogFiles()
+	l.runlock()
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+
+	matches := make([]string, 0)
+	for _, f := range files {
+		if max > 0 && len(matches) >= max {
+			break
+		}
+		r, err := openLogFile(l.fs, f.name, l.aead)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, NewFaultWrap(FAILED, "FAILED", err)
+		}
+		matches, err = searchReader(r, re, max, matches)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// append to matches the lines of r matching re, until there are max of
+// them unless max is 0. A line longer than maxSearchLine is matched and
+// returned cut to its first maxSearchLine bytes
+func searchReader(r io.Reader, re *regexp.Regexp, max int, matches []string) ([]string, error) {
+	br := bufio.NewReader(r)
+	line := make([]byte, 0, 4096)
+	for max == 0 || len(matches) < max {
+		chunk, err := br.ReadSlice('\n')
+		if room := maxSearchLine - len(line); len(chunk) > room {
+			chunk = chunk[:room]
+		}
+		line = append(line, chunk...)
+		//the rest of a long line is read in the next chunks
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return nil, NewFaultWrap(FAILED, "FAILED", err)
+		}
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		//the line without its newline, like bufio.ScanLines returns it
+		b := bytes.TrimSuffix(line, []byte("\n"))
+		b = bytes.TrimSuffix(b, []byte("\r"))
+		if re.Match(b) {
+			matches = append(matches, string(b))
+		}
+		line = line[:0]
+		if err == io.EOF {
+			break
+		}
+	}
+	return matches, nil
+}
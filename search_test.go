@@ -0,0 +1,100 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestSearch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name)
+	write(t, l, "GET /a 200\n", "POST /b 500\n", "GET /c 404\r\n", "GET /d 500")
+	tests := []struct {
+		pattern string
+		max     int
+		want    []string
+	}{
+		{`GET`, 0, []string{"GET /a 200", "GET /c 404", "GET /d 500"}},
+		{`GET`, 2, []string{"GET /a 200", "GET /c 404"}},
+		{` 5\d\d$`, 0, []string{"POST /b 500", "GET /d 500"}},
+		{`404$`, 0, []string{"GET /c 404"}},
+		{`PUT`, 0, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := l.Search(tt.pattern, tt.max)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q, %d) = %q, %v, want %q", tt.pattern, tt.max, got, err, tt.want)
+		}
+	}
+	for _, args := range []struct {
+		pattern string
+		max     int
+	}{{`(`, 0}, {`GET`, -1}} {
+		_, err := l.Search(args.pattern, args.max)
+		var fault *core.Fault
+		if !errors.As(err, &fault) || fault.Code != core.BAD_ARGUMENTS {
+			t.Errorf("Search(%q, %d) = %v, want a BAD_ARGUMENTS fault", args.pattern, args.max, err)
+		}
+	}
+}
+
+func TestSearchLongLines(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithMaxSize(0))
+	long := "match " + strings.Repeat("x", 100<<10)
+	huge := "match " + strings.Repeat("y", 2<<20)
+	write(t, l, "match before\n", long+"\n", huge+"\n", "match after\n")
+
+	got, err := l.Search(`^match`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("%d matches, want 4", len(got))
+	}
+	if got[0] != "match before" || got[1] != long || got[3] != "match after" {
+		t.Errorf("matches %.20q, want the lines around the long ones unchanged", got)
+	}
+	//a line over the limit is cut, the search goes on after it
+	if got[2] != huge[:1<<20] {
+		t.Errorf("huge line returned with %d bytes, want it cut to %d", len(got[2]), 1<<20)
+	}
+	if got, err := l.Search(`y$`, 0); err != nil || len(got) != 1 {
+		t.Errorf("Search(y$) = %d matches, %v, want the cut line", len(got), err)
+	}
+}
+
+func TestSearchAcrossRotatedFiles(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		name := filepath.Join(t.TempDir(), "test.log")
+		l := newLogger(t, name, core.WithMaxSize(30), core.WithBackups(4), core.WithCompress(compress))
+		//three lines of 10 bytes per file
+		for i := 0; i < 10; i++ {
+			write(t, l, fmt.Sprintf("line %d %s\n", i, []string{"ok", "KO"}[i%2]))
+		}
+		l.Close()
+
+		got, err := l.SearchAll(`KO`, 0)
+		want := []string{"line 1 KO", "line 3 KO", "line 5 KO", "line 7 KO", "line 9 KO"}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("compress %t: SearchAll = %q, %v, want %q", compress, got, err, want)
+		}
+		//max stops in the middle of a backup
+		got, err = l.SearchAll(`line [2-8]`, 4)
+		want = []string{"line 2 ok", "line 3 KO", "line 4 ok", "line 5 KO"}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("compress %t: SearchAll with max = %q, %v, want %q", compress, got, err, want)
+		}
+		got, err = l.Search(`KO`, 0)
+		want = []string{"line 9 KO"}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("compress %t: Search = %q, %v, want %q of the current file", compress, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Package sftplog provides a Logger appending to a file on a remote host
+// over SFTP. It knows no SFTP library, the connection is made through the
+// Client interface so the fileutils package stays free of dependencies
+package sftplog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+const (
+	// first and longest wait before trying to reconnect
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// File is a remote file opened by a Client
+type File interface {
+	io.Writer
+	io.ReaderAt
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// Client is the part of an SFTP client the logger uses. Wrapping the client
+// of an SFTP library, like github.com/pkg/sftp, takes a few lines
+type Client interface {
+	OpenFile(path string, flag int) (File, error)
+	Rename(oldpath string, newpath string) error
+	Remove(path string) error
+	Close() error
+}
+
+// Dialer opens a new connection to the remote host
+type Dialer func() (Client, error)
+
+// SFTPLogger appends the records to a remote file. When the connection is
+// lost the records are kept in memory, up to maxBuffered bytes with the
+// oldest ones dropped first, and written once a new connection is made.
+// The reconnection is tried on the next writes with an exponential backoff.
+//
+// The rotation is emulated with renames: name.<backups-1> becomes
+// name.<backups> and so on, the current file becomes name.1 and a new name
+// is created
+type SFTPLogger struct {
+	dial        Dialer
+	name        string
+	maxSize     int64
+	backups     int
+	maxBuffered int
+
+	lock        sync.Mutex
+	client      Client
+	file        File
+	fileSize    int64
+	pending     [][]byte
+	pendingSize int
+	dropped     int64
+	backoff     time.Duration
+	retryAt     time.Time
+	// set by Close
+	closed bool
+
+	// counters returned by Stats
+	rotations    int64
+	bytesWritten int64
+}
+
+func NewSFTPLogger(dial Dialer, name string, maxSize int64, backups int, maxBuffered int) *SFTPLogger {
+	return &SFTPLogger{dial: dial,
+		name:        name,
+		maxSize:     maxSize,
+		backups:     backups,
+		maxBuffered: maxBuffered}
+}
+
+// connect to the remote host and open the log file if not done yet
+func (l *SFTPLogger) connect() error {
+	if l.closed {
+		return core.ErrLoggerClosed
+	}
+	if l.client != nil {
+		return nil
+	}
+	client, err := l.dial()
+	if err != nil {
+		return err
+	}
+	file, err := client.OpenFile(l.name, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	statInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		client.Close()
+		return err
+	}
+	l.client = client
+	l.file = file
+	l.fileSize = statInfo.Size()
+	l.backoff = 0
+	return nil
+}
+
+// drop the connection and wait before trying again
+func (l *SFTPLogger) disconnect() {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+	if l.client != nil {
+		l.client.Close()
+		l.client = nil
+	}
+	if l.backoff == 0 {
+		l.backoff = minBackoff
+	} else if l.backoff < maxBackoff {
+		l.backoff *= 2
+		if l.backoff > maxBackoff {
+			l.backoff = maxBackoff
+		}
+	}
+	l.retryAt = time.Now().Add(l.backoff)
+}
+
+// keep a record until the connection is back, dropping the oldest ones
+func (l *SFTPLogger) keep(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	l.pending = append(l.pending, append([]byte(nil), p...))
+	l.pendingSize += len(p)
+	for l.pendingSize > l.maxBuffered && len(l.pending) > 0 {
+		l.pendingSize -= len(l.pending[0])
+		l.pending = l.pending[1:]
+		l.dropped++
+	}
+}
+
+// write a record to the remote file and rotate it if needed, return how
+// many bytes of p reached the file: all of them when only the rotation
+// failed. A failed rotation is done again by the write following the
+// reconnection, the file being still full
+func (l *SFTPLogger) send(p []byte) (int, error) {
+	n, err := l.file.Write(p)
+	l.fileSize += int64(n)
+	l.bytesWritten += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if l.maxSize > 0 && l.fileSize >= l.maxSize {
+		return n, l.rotate()
+	}
+	return n, nil
+}
+
+// shift the backups by renaming them and start a new remote file
+func (l *SFTPLogger) rotate() error {
+	l.file.Close()
+	l.file = nil
+	if l.backups > 0 {
+		l.client.Remove(fmt.Sprintf("%s.%d", l.name, l.backups))
+		for i := l.backups - 1; i >= 1; i-- {
+			l.client.Rename(fmt.Sprintf("%s.%d", l.name, i), fmt.Sprintf("%s.%d", l.name, i+1))
+		}
+		if err := l.client.Rename(l.name, l.name+".1"); err != nil {
+			return err
+		}
+	}
+	file, err := l.client.OpenFile(l.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	l.file = file
+	l.fileSize = 0
+	l.rotations++
+	return nil
+}
+
+// write the records kept during an outage, the caller must hold the lock
+func (l *SFTPLogger) flushPending() error {
+	for len(l.pending) > 0 {
+		n, err := l.send(l.pending[0])
+		//only the part not written yet is kept
+		l.pendingSize -= n
+		if n == len(l.pending[0]) {
+			l.pending = l.pending[1:]
+		} else {
+			l.pending[0] = l.pending[0][n:]
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write sends p to the remote file, or keeps the part of p not written if
+// the host can't be reached. It returns ErrLoggerClosed after Close
+func (l *SFTPLogger) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return 0, core.ErrLoggerClosed
+	}
+	if l.client == nil && time.Now().After(l.retryAt) {
+		if err := l.connect(); err != nil {
+			l.disconnect()
+		}
+	}
+	if l.client != nil {
+		if err := l.flushPending(); err != nil {
+			l.disconnect()
+			l.keep(p)
+			return len(p), nil
+		}
+		n, err := l.send(p)
+		if err == nil {
+			return len(p), nil
+		}
+		l.disconnect()
+		l.keep(p[n:])
+		return len(p), nil
+	}
+	l.keep(p)
+	return len(p), nil
+}
+
+func (l *SFTPLogger) WriteLine(s string) (int, error) {
+	return l.Write(core.JoinLines([]string{s}))
+}
+
+func (l *SFTPLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(core.JoinLines(ss))
+}
+
+// Dropped returns the number of records dropped because the buffer was full
+// during an outage
+func (l *SFTPLogger) Dropped() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.dropped
+}
+
+// Close tries once to write the kept records, then closes the connection.
+// The logger can't be used after
+func (l *SFTPLogger) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return core.ErrLoggerClosed
+	}
+	var err error
+	if len(l.pending) > 0 {
+		if err = l.connect(); err == nil {
+			err = l.flushPending()
+		}
+	}
+	l.disconnect()
+	l.closed = true
+	return err
+}
+
+// open the remote log file for reading
+func (l *SFTPLogger) openRead() (File, int64, error) {
+	if err := l.connect(); err != nil {
+		l.disconnect()
+		return nil, 0, core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	f, err := l.client.OpenFile(l.name, os.O_RDONLY)
+	if err != nil {
+		return nil, 0, core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	statInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	return f, statInfo.Size(), nil
+}
+
+func (l *SFTPLogger) ReadLog(offset int64, length int64) (string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, size, err := l.openRead()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	b, err := core.ReadAtRange(f, size, offset, length)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (l *SFTPLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, size, err := l.openRead()
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer f.Close()
+	return core.ReadTailAt(f, size, offset, length)
+}
+
+func (l *SFTPLogger) ReadTailLines(n int) ([]string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, size, err := l.openRead()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return core.ReadTailLinesAt(f, size, n)
+}
+
+func (l *SFTPLogger) LineCount() (int64, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, size, err := l.openRead()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return core.CountLines(io.NewSectionReader(f, 0, size))
+}
+
+// Stats returns the counters of the logger, the records kept during an
+// outage are not written yet
+func (l *SFTPLogger) Stats() core.LoggerStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return core.LoggerStats{RotationCount: l.rotations,
+		BytesWritten:    l.bytesWritten,
+		CurrentFileSize: l.fileSize,
+		CurrentFile:     l.name}
+}
+
+// Sync commits the remote file if its client supports it, with the fsync
+// extension of OpenSSH for example, and is a no-op otherwise
+func (l *SFTPLogger) Sync() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if f, ok := l.file.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (l *SFTPLogger) ClearCurLogFile() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := l.connect(); err != nil {
+		l.disconnect()
+		return core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	file, err := l.client.OpenFile(l.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	l.file.Close()
+	l.file = file
+	l.fileSize = 0
+	return nil
+}
+
+func (l *SFTPLogger) ClearAllLogFile() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := l.connect(); err != nil {
+		l.disconnect()
+		return core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	for i := 1; i <= l.backups; i++ {
+		l.client.Remove(fmt.Sprintf("%s.%d", l.name, i))
+	}
+	file, err := l.client.OpenFile(l.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	l.file.Close()
+	l.file = file
+	l.fileSize = 0
+	return nil
+}
+
+// ClearOldestBackup removes the backup with the highest number, which is
+// the oldest one, and returns its name. core.ErrNoBackup is returned when
+// there is no backup
+func (l *SFTPLogger) ClearOldestBackup() (string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := l.connect(); err != nil {
+		l.disconnect()
+		return "", core.NewFaultWrap(core.FAILED, "FAILED", err)
+	}
+	for i := l.backups; i >= 1; i-- {
+		name := fmt.Sprintf("%s.%d", l.name, i)
+		if err := l.client.Remove(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", core.ErrNoBackup
+}
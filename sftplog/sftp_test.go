@@ -0,0 +1,292 @@
+package sftplog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// remote is an in memory SFTP server
+type remote struct {
+	lock  sync.Mutex
+	files map[string][]byte
+	dials int
+	// fail the dials, the renames, or the writes once writeLimit bytes are
+	// written when it is not negative
+	failDial   bool
+	failRename bool
+	writeLimit int
+}
+
+func newRemote() *remote {
+	return &remote{files: make(map[string][]byte), writeLimit: -1}
+}
+
+func (r *remote) dial() (Client, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.dials++
+	if r.failDial {
+		return nil, errors.New("connection refused")
+	}
+	return remoteClient{r}, nil
+}
+
+func (r *remote) content(name string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return string(r.files[name])
+}
+
+type remoteClient struct {
+	r *remote
+}
+
+func (c remoteClient) OpenFile(path string, flag int) (File, error) {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	if _, ok := c.r.files[path]; !ok || flag&os.O_TRUNC != 0 {
+		if !ok && flag&os.O_CREATE == 0 {
+			return nil, os.ErrNotExist
+		}
+		c.r.files[path] = nil
+	}
+	return &remoteFile{r: c.r, name: path}, nil
+}
+
+func (c remoteClient) Rename(oldpath string, newpath string) error {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	if c.r.failRename {
+		return errors.New("rename failed")
+	}
+	b, ok := c.r.files[oldpath]
+	if !ok {
+		return os.ErrNotExist
+	}
+	c.r.files[newpath] = b
+	delete(c.r.files, oldpath)
+	return nil
+}
+
+func (c remoteClient) Remove(path string) error {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	if _, ok := c.r.files[path]; !ok {
+		return os.ErrNotExist
+	}
+	delete(c.r.files, path)
+	return nil
+}
+
+func (c remoteClient) Close() error {
+	return nil
+}
+
+type remoteFile struct {
+	r    *remote
+	name string
+}
+
+func (f *remoteFile) Write(p []byte) (int, error) {
+	f.r.lock.Lock()
+	defer f.r.lock.Unlock()
+
+	n := len(p)
+	if f.r.writeLimit >= 0 && n > f.r.writeLimit {
+		n = f.r.writeLimit
+	}
+	f.r.files[f.name] = append(f.r.files[f.name], p[:n]...)
+	if f.r.writeLimit >= 0 {
+		f.r.writeLimit -= n
+		if n < len(p) {
+			return n, errors.New("connection lost")
+		}
+	}
+	return n, nil
+}
+
+func (f *remoteFile) ReadAt(p []byte, off int64) (int, error) {
+	f.r.lock.Lock()
+	defer f.r.lock.Unlock()
+
+	b := f.r.files[f.name]
+	if off >= int64(len(b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *remoteFile) Close() error {
+	return nil
+}
+
+func (f *remoteFile) Stat() (os.FileInfo, error) {
+	f.r.lock.Lock()
+	defer f.r.lock.Unlock()
+
+	return remoteInfo{name: f.name, size: int64(len(f.r.files[f.name]))}, nil
+}
+
+type remoteInfo struct {
+	name string
+	size int64
+}
+
+func (i remoteInfo) Name() string       { return i.name }
+func (i remoteInfo) Size() int64        { return i.size }
+func (i remoteInfo) Mode() os.FileMode  { return 0644 }
+func (i remoteInfo) ModTime() time.Time { return time.Time{} }
+func (i remoteInfo) IsDir() bool        { return false }
+func (i remoteInfo) Sys() interface{}   { return nil }
+
+// let the next write reconnect without waiting for the backoff
+func retryNow(l *SFTPLogger) {
+	l.lock.Lock()
+	l.retryAt = time.Time{}
+	l.lock.Unlock()
+}
+
+func TestSFTPLoggerWrite(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 1024)
+	defer l.Close()
+
+	l.Write([]byte("one\n"))
+	l.Write([]byte("two\n"))
+	if got := r.content("app.log"); got != "one\ntwo\n" {
+		t.Fatalf("remote file is %q", got)
+	}
+}
+
+func TestSFTPLoggerRotate(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 8, 3, 1024)
+	defer l.Close()
+
+	l.Write([]byte("0123\n"))
+	l.Write([]byte("4567\n"))
+	l.Write([]byte("89\n"))
+	if got := r.content("app.log.1"); got != "0123\n4567\n" {
+		t.Fatalf("backup is %q", got)
+	}
+	if got := r.content("app.log"); got != "89\n" {
+		t.Fatalf("current file is %q", got)
+	}
+}
+
+func TestSFTPLoggerOutage(t *testing.T) {
+	r := newRemote()
+	r.failDial = true
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 1024)
+	defer l.Close()
+
+	l.Write([]byte("kept\n"))
+	r.lock.Lock()
+	r.failDial = false
+	r.lock.Unlock()
+	retryNow(l)
+	l.Write([]byte("live\n"))
+	if got := r.content("app.log"); got != "kept\nlive\n" {
+		t.Fatalf("remote file is %q", got)
+	}
+}
+
+func TestSFTPLoggerOutageDropsOldest(t *testing.T) {
+	r := newRemote()
+	r.failDial = true
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 10)
+	defer l.Close()
+
+	l.Write([]byte("first\n"))
+	l.Write([]byte("second\n"))
+	if l.Dropped() != 1 {
+		t.Fatalf("%d records dropped", l.Dropped())
+	}
+}
+
+func TestSFTPLoggerRotateFailureDoesNotDuplicate(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 4, 3, 1024)
+	defer l.Close()
+
+	r.failRename = true
+	l.Write([]byte("full\n"))
+	r.lock.Lock()
+	r.failRename = false
+	r.lock.Unlock()
+	retryNow(l)
+	l.Write([]byte("next\n"))
+
+	all := r.content("app.log.1") + r.content("app.log")
+	if all != "full\nnext\n" {
+		t.Fatalf("remote files hold %q", all)
+	}
+}
+
+func TestSFTPLoggerPartialWrite(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 1024)
+	defer l.Close()
+
+	r.writeLimit = 3
+	l.Write([]byte("partial\n"))
+	r.lock.Lock()
+	r.writeLimit = -1
+	r.lock.Unlock()
+	retryNow(l)
+	l.Write([]byte("next\n"))
+	if got := r.content("app.log"); got != "partial\nnext\n" {
+		t.Fatalf("remote file is %q", got)
+	}
+}
+
+func TestSFTPLoggerWriteAfterClose(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 1024)
+	l.Write([]byte("one\n"))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dials := r.dials
+	if _, err := l.Write([]byte("two\n")); err != core.ErrLoggerClosed {
+		t.Fatalf("write after close returned %v", err)
+	}
+	if _, err := l.ReadLog(0, 0); err == nil {
+		t.Fatal("read after close succeeded")
+	}
+	if r.dials != dials {
+		t.Fatal("the logger reconnected after close")
+	}
+	if got := r.content("app.log"); got != "one\n" {
+		t.Fatalf("remote file is %q", got)
+	}
+}
+
+func TestSFTPLoggerReadLog(t *testing.T) {
+	r := newRemote()
+	l := NewSFTPLogger(r.dial, "app.log", 0, 3, 1024)
+	defer l.Close()
+
+	l.Write([]byte("one\ntwo\n"))
+	if got, err := l.ReadLog(4, 0); err != nil || got != "two\n" {
+		t.Fatalf("ReadLog returned %q %v", got, err)
+	}
+	if n, err := l.LineCount(); err != nil || n != 2 {
+		t.Fatalf("LineCount returned %d %v", n, err)
+	}
+}
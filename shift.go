@@ -0,0 +1,139 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// the suffix of the empty file replacing the RotateShift current file
+const freshSuffix = ".new"
+
+// rename every RotateShift backup to the next number, dropping the oldest,
+// then the current file to name.1 and put an empty file in its place. The
+// current file is left open and goes on as name.1 until a new one is
+// opened. The caller must hold the lock.
+//
+// A crash never loses a file: the current one is hard linked as name.1
+// before an empty file is renamed over it, so name always exists. A crash
+// between the two steps leaves name and name.1 the same file, which
+// recoverShift finishes at the next start
+func (l *FileLogger) shiftLogFiles() error {
+	oldest := l.getLogFileName(l.ringSize())
+	l.discardCompressed(oldest)
+	if err := l.fs.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := l.ringSize() - 1; n >= 1; n-- {
+		if err := l.renameBackup(l.getLogFileName(n), l.getLogFileName(n+1)); err != nil {
+			return err
+		}
+	}
+	//without hard links, on the file system or across devices, name is
+	//missing for a moment
+	lfs, ok := l.fs.(linkFS)
+	if !ok {
+		return l.renameBackup(l.name, l.getLogFileName(1))
+	}
+	if err := lfs.Link(l.name, l.getLogFileName(1)); err != nil && !os.IsNotExist(err) {
+		return l.renameBackup(l.name, l.getLogFileName(1))
+	}
+	return l.replaceShiftCurrent()
+}
+
+// replace the RotateShift current file by an empty one with a rename, so it
+// is never missing
+func (l *FileLogger) replaceShiftCurrent() error {
+	fresh := l.name + freshSuffix
+	f, err := l.fs.OpenFile(fresh, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, l.fileMode)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return l.fs.Rename(fresh, l.name)
+}
+
+// finish a RotateShift rotation interrupted by a crash after the current
+// file was linked as name.1, the current file then starts over empty
+func (l *FileLogger) recoverShift() error {
+	cur, err := l.fs.Stat(l.name)
+	if err != nil {
+		return nil
+	}
+	prev, err := l.fs.Stat(l.getLogFileName(1))
+	if err != nil || !os.SameFile(cur, prev) {
+		return nil
+	}
+	return l.replaceShiftCurrent()
+}
+
+// rename a log file, its compressed version and its checksum, a
+// compression or a hash in progress follows the file. A missing file is
+// not an error
+func (l *FileLogger) renameBackup(from string, to string) error {
+	for _, suffix := range append([]string{"", checksumSuffix}, compressExtensions()...) {
+		if err := l.fs.Rename(from+suffix, to+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if job, ok := l.compressing[from]; ok {
+		delete(l.compressing, from)
+		job.name = to
+		l.compressing[to] = job
+	}
+	if job, ok := l.checksumming[from]; ok {
+		delete(l.checksumming, from)
+		job.name = to
+		l.checksumming[to] = job
+	}
+	return nil
+}
+
+// open the RotateShift current file, rotating it first if it is full
+func (l *FileLogger) updateLatestShiftLog() error {
+	err := l.recoverShift()
+	if err == nil {
+		err = l.openCurrent()
+	}
+	if err == nil && l.full() {
+		if err = l.shiftLogFiles(); err == nil {
+			err = l.openFile(true)
+		}
+	}
+	if err != nil {
+		l.handleError(err)
+	}
+	return err
+}
+
+// return the RotateShift backups of the directory entries, oldest first,
+// then the current file
+func (l *FileLogger) listShiftLogFiles(entries []os.FileInfo) []logFile {
+	found := make(map[int]os.FileInfo)
+	var current os.FileInfo
+	for _, fileInfo := range entries {
+		if fileInfo.Name() == filepath.Base(l.name) {
+			current = fileInfo
+			continue
+		}
+		if n, ok := l.rotateIndex(fileInfo); ok {
+			if prev, dup := found[n]; dup && !isCompressed(prev.Name()) {
+				continue
+			}
+			found[n] = fileInfo
+		}
+	}
+	files := make([]logFile, 0, len(found)+1)
+	for n, fileInfo := range found {
+		name := l.getLogFileName(n)
+		name += compressedExt(fileInfo.Name())
+		files = append(files, logFile{name: name, info: fileInfo, index: n})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].index > files[j].index
+	})
+	if current != nil {
+		files = append(files, logFile{name: l.name, info: current})
+	}
+	return files
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// an FS whose hard links always fail
+type noLinkFS struct {
+	osFS
+}
+
+func (noLinkFS) Link(oldname string, newname string) error {
+	return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: errors.New("operation not permitted")}
+}
+
+func readTestFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestShiftRotation(t *testing.T) {
+	for _, fs := range []FS{osFS{}, noLinkFS{}} {
+		l := newTestLogger(t, 10, 3, WithRotationStrategy(RotateShift), WithFS(fs))
+		for _, line := range []string{"aaaaaaaaa\n", "bbbbbbbbb\n", "ccccccccc\n", "ddd\n"} {
+			if _, err := l.Write([]byte(line)); err != nil {
+				t.Fatalf("%T: %v", fs, err)
+			}
+		}
+		want := map[string]string{l.name: "ddd\n",
+			l.name + ".1": "ccccccccc\n",
+			l.name + ".2": "bbbbbbbbb\n",
+			l.name + ".3": "aaaaaaaaa\n"}
+		for name, content := range want {
+			if got := readTestFile(t, name); got != content {
+				t.Fatalf("%T: %s holds %q, want %q", fs, name, got, content)
+			}
+		}
+		if l.GetCurrentLogFile() != l.name {
+			t.Fatalf("%T: current file is %s", fs, l.GetCurrentLogFile())
+		}
+	}
+}
+
+func TestShiftRestart(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := NewFileLogger(name, 10, 3, nil, WithRotationStrategy(RotateShift))
+	l.Write([]byte("aaaaaaaaa\n"))
+	l.Write([]byte("bbb\n"))
+	l.Close()
+
+	restarted, err := NewFileLoggerE(name, 10, 3, nil, WithRotationStrategy(RotateShift))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if restarted.GetCurrentLogFile() != name {
+		t.Fatalf("restarted on %s", restarted.GetCurrentLogFile())
+	}
+	restarted.Write([]byte("ccc\n"))
+	if got := readTestFile(t, name); got != "bbb\nccc\n" {
+		t.Fatalf("current file holds %q", got)
+	}
+	if got := readTestFile(t, name+".1"); got != "aaaaaaaaa\n" {
+		t.Fatalf("backup holds %q", got)
+	}
+}
+
+func TestShiftRecoverInterruptedRotation(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	os.WriteFile(name, []byte("rotated\n"), 0644)
+	//a crash after the link leaves name and name.1 the same file
+	if err := os.Link(name, name+".1"); err != nil {
+		t.Skip("no hard links:", err)
+	}
+
+	l, err := NewFileLoggerE(name, 100, 3, nil, WithRotationStrategy(RotateShift))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Write([]byte("new\n"))
+	if got := readTestFile(t, name); got != "new\n" {
+		t.Fatalf("current file holds %q", got)
+	}
+	if got := readTestFile(t, name+".1"); got != "rotated\n" {
+		t.Fatalf("backup holds %q", got)
+	}
+	if _, err := os.Stat(name + freshSuffix); !os.IsNotExist(err) {
+		t.Fatalf("%s left behind", freshSuffix)
+	}
+	if s, _ := l.ReadCombinedLog(0, 0); !strings.HasPrefix(s, "rotated\n") {
+		t.Fatalf("combined log is %q", s)
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the size units understood by ParseSize, longest suffixes first so KiB is
+// not taken for B
+var sizeUnits = []struct {
+	suffix string
+	scale  float64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"B", 1},
+}
+
+// ParseSize parses a size in bytes like "1024", "10MB" or "1.5GiB". KB, MB
+// and GB are decimal units and KiB, MiB and GiB binary ones, the case does
+// not matter and a space may separate the number from the unit. An invalid
+// or negative size returns a BAD_ARGUMENTS Fault
+func ParseSize(s string) (int64, error) {
+	num := strings.TrimSpace(s)
+	scale := float64(1)
+	upper := strings.ToUpper(num)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			num = strings.TrimSpace(num[:len(num)-len(unit.suffix)])
+			scale = unit.scale
+			break
+		}
+	}
+	//a plain byte count is parsed exactly
+	if scale == 1 {
+		if n, err := strconv.ParseInt(num, 10, 64); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) || f*scale >= math.MaxInt64 {
+		return 0, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	return int64(f * scale), nil
+}
+
+// NewFileLoggerFromString creates a FileLogger like NewFileLoggerE with the
+// maximum size given as a string read by ParseSize, like "10MB"
+func NewFileLoggerFromString(name string, maxSize string, backups int, locker sync.Locker, opts ...Option) (*FileLogger, error) {
+	size, err := ParseSize(maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileLoggerE(name, size, backups, locker, opts...)
+}
@@ -0,0 +1,65 @@
+package core_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"9223372036854775807", 1<<63 - 1},
+		{"10MB", 10e6},
+		{"10mb", 10e6},
+		{"10 MB", 10e6},
+		{" 10MB ", 10e6},
+		{"1.5GiB", 3 << 29},
+		{"2KiB", 2048},
+		{"2KB", 2000},
+		{"1MiB", 1 << 20},
+		{"3GB", 3e9},
+		{"512B", 512},
+		{"0.5KiB", 512},
+	}
+	for _, tt := range tests {
+		got, err := core.ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, in := range []string{"", "MB", "-1", "-1MB", "ten", "10XB", "10 M B", "1e400", "NaN", "Inf", "1e10GB", "0x10", "9223372036854775808", "8589934592GiB"} {
+		_, err := core.ParseSize(in)
+		var fault *core.Fault
+		if !errors.As(err, &fault) || fault.Code != core.BAD_ARGUMENTS {
+			t.Errorf("ParseSize(%q) = %v, want a BAD_ARGUMENTS fault", in, err)
+		}
+	}
+}
+
+func TestNewFileLoggerFromString(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	if _, err := core.NewFileLoggerFromString(name, "10 parsecs", 3, nil); err == nil {
+		t.Error("no error for an invalid size")
+	}
+	l, err := core.NewFileLoggerFromString(name, "1KB", 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	write(t, l, string(make([]byte, 999)))
+	first := l.GetCurrentLogFile()
+	write(t, l, "\n")
+	if l.GetCurrentLogFile() == first {
+		t.Error("not rotated at 1000 bytes")
+	}
+}
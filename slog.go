@@ -0,0 +1,50 @@
+//go:build go1.21
+
+package core
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// SlogHandlerOptions configures the handler made by NewSlogHandler
+type SlogHandlerOptions struct {
+	slog.HandlerOptions
+	// JSON formats the records as JSON objects instead of key=value text
+	JSON bool
+}
+
+// NewSlogHandler returns a slog.Handler writing the records to logger, one
+// line per record and each line in a single Write, so the records end up in
+// the rotated files of a FileLogger like any other write. The handler and
+// the ones made by its WithAttrs and WithGroup methods are safe for
+// concurrent use. A nil opts formats the records as text with the default
+// options. The labels of a Labeled logger are added to every record as
+// attributes, sorted by key
+func NewSlogHandler(logger Logger, opts *SlogHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &SlogHandlerOptions{}
+	}
+	var h slog.Handler
+	if opts.JSON {
+		h = slog.NewJSONHandler(logger, &opts.HandlerOptions)
+	} else {
+		h = slog.NewTextHandler(logger, &opts.HandlerOptions)
+	}
+	if labeled, ok := logger.(Labeled); ok {
+		if attrs := labelAttrs(labeled.Labels()); len(attrs) > 0 {
+			h = h.WithAttrs(attrs)
+		}
+	}
+	return h
+}
+
+// return labels as string attributes sorted by key
+func labelAttrs(labels map[string]string) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(labels))
+	for k, v := range labels {
+		attrs = append(attrs, slog.String(k, v))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
@@ -0,0 +1,98 @@
+//go:build go1.21
+
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+func TestSlogHandlerLabels(t *testing.T) {
+	file := newLogger(t, filepath.Join(t.TempDir(), "test.log"), core.WithLabel(map[string]string{"component": "auth", "env": "prod"}))
+	mem := core.NewMemoryLogger()
+	h := core.NewSlogHandler(core.NewMultiLogger([]core.Logger{file, mem}), &core.SlogHandlerOptions{JSON: true})
+	slog.New(h).Info("login", "user", "alice")
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(mem.String()), &record); err != nil {
+		t.Fatalf("record %q: %v", mem.String(), err)
+	}
+	for k, v := range map[string]string{"component": "auth", "env": "prod", "user": "alice", "msg": "login"} {
+		if record[k] != v {
+			t.Errorf("record %s = %v, want %q", k, record[k], v)
+		}
+	}
+}
+
+// drop the time of the records so they can be compared
+func withoutTime(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+func TestSlogHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  bool
+		level slog.Level
+		log   func(l *slog.Logger)
+		want  string
+	}{
+		{"message", false, slog.LevelInfo, func(l *slog.Logger) { l.Info("hello") },
+			"level=INFO msg=hello\n"},
+		{"attributes", false, slog.LevelInfo, func(l *slog.Logger) { l.Warn("login", "user", "alice", "attempt", 3) },
+			"level=WARN msg=login user=alice attempt=3\n"},
+		{"group attribute", false, slog.LevelInfo, func(l *slog.Logger) { l.Info("req", slog.Group("http", "method", "GET", "status", 200)) },
+			"level=INFO msg=req http.method=GET http.status=200\n"},
+		{"WithAttrs", false, slog.LevelInfo, func(l *slog.Logger) { l.With("component", "auth").Info("a"); l.Info("b") },
+			"level=INFO msg=a component=auth\nlevel=INFO msg=b\n"},
+		{"WithGroup", false, slog.LevelInfo, func(l *slog.Logger) { l.WithGroup("req").With("id", 7).Info("done", "ms", 12) },
+			"level=INFO msg=done req.id=7 req.ms=12\n"},
+		{"level filtered", false, slog.LevelWarn, func(l *slog.Logger) { l.Debug("d"); l.Info("i"); l.Warn("w"); l.Error("e") },
+			"level=WARN msg=w\nlevel=ERROR msg=e\n"},
+		{"debug enabled", false, slog.LevelDebug, func(l *slog.Logger) { l.Debug("d") },
+			"level=DEBUG msg=d\n"},
+		{"json attributes", true, slog.LevelInfo, func(l *slog.Logger) { l.Info("login", "user", "alice") },
+			`{"level":"INFO","msg":"login","user":"alice"}` + "\n"},
+		{"json WithGroup", true, slog.LevelInfo, func(l *slog.Logger) { l.WithGroup("req").With("id", 7).Info("done", "ms", 12) },
+			`{"level":"INFO","msg":"done","req":{"id":7,"ms":12}}` + "\n"},
+		{"json level filtered", true, slog.LevelError, func(l *slog.Logger) { l.Warn("w"); l.Error("e") },
+			`{"level":"ERROR","msg":"e"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			under := newGateLogger(false)
+			h := core.NewSlogHandler(under, &core.SlogHandlerOptions{
+				HandlerOptions: slog.HandlerOptions{Level: tt.level, ReplaceAttr: withoutTime},
+				JSON:           tt.json})
+			tt.log(slog.New(h))
+			if got := under.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			//one write per record
+			if n, want := len(under.received()), strings.Count(tt.want, "\n"); n != want {
+				t.Errorf("%d writes for %d records", n, want)
+			}
+		})
+	}
+}
+
+func TestSlogHandlerDefaults(t *testing.T) {
+	mem := core.NewMemoryLogger()
+	h := core.NewSlogHandler(mem, nil)
+	if h.Enabled(context.Background(), slog.LevelDebug) || !h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("a nil opts does not log from the Info level")
+	}
+	slog.New(h).Info("hello", "k", "v")
+	if got := mem.String(); !strings.HasPrefix(got, "time=") || !strings.HasSuffix(got, " level=INFO msg=hello k=v\n") {
+		t.Errorf("got %q, want a text record", got)
+	}
+}
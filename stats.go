@@ -0,0 +1,25 @@
+package core
+
+// LoggerStats are the counters of a Logger since it was created
+type LoggerStats struct {
+	// RotationCount is the number of rotations done
+	RotationCount int64
+	// BytesWritten is the number of bytes written to the log files
+	BytesWritten int64
+	// CurrentFileSize is the size of the current log file, buffered bytes
+	// included
+	CurrentFileSize int64
+	// CurrentFile is the name of the current log file
+	CurrentFile string
+}
+
+// Stats returns the counters of the logger
+func (l *FileLogger) Stats() LoggerStats {
+	l.rlock()
+	defer l.runlock()
+
+	return LoggerStats{RotationCount: l.rotations,
+		BytesWritten:    l.bytesWritten,
+		CurrentFileSize: l.fileSize,
+		CurrentFile:     l.currentLogFile()}
+}
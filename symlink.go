@@ -0,0 +1,34 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WithSymlink keeps a symbolic link at the logger name, without any suffix,
+// pointing to the current log file, so the tools following a fixed path
+// keep up with the rotations. The link is replaced atomically by a rename.
+// It does nothing with RotateShift, whose current file already has that
+// name. Where symbolic links are not allowed, like Windows without the
+// privilege, the error goes to the error handler and the logs are written
+// as usual
+func WithSymlink(symlink bool) Option {
+	return func(l *FileLogger) {
+		l.symlink = symlink
+	}
+}
+
+// point the symlink to the current log file, the caller must hold the lock
+func (l *FileLogger) updateSymlink() error {
+	tmpName := l.name + ".link.tmp"
+	l.fs.Remove(tmpName)
+	//a relative target keeps working if the directory is moved
+	if err := os.Symlink(filepath.Base(l.currentLogFile()), tmpName); err != nil {
+		return err
+	}
+	if err := l.fs.Rename(tmpName, l.name); err != nil {
+		l.fs.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"io"
+)
+
+// SyslogFacility is the facility of the syslog messages, with the values of
+// log/syslog
+type SyslogFacility int
+
+const (
+	SyslogKern SyslogFacility = iota << 3
+	SyslogUser
+	SyslogMail
+	SyslogDaemon
+	SyslogAuth
+	SyslogSyslog
+	SyslogLpr
+	SyslogNews
+	SyslogUucp
+	SyslogCron
+	SyslogAuthpriv
+	SyslogFtp
+)
+
+const (
+	SyslogLocal0 SyslogFacility = (16 + iota) << 3
+	SyslogLocal1
+	SyslogLocal2
+	SyslogLocal3
+	SyslogLocal4
+	SyslogLocal5
+	SyslogLocal6
+	SyslogLocal7
+)
+
+// SyslogSeverity is the severity of the syslog messages, with the values
+// of log/syslog
+type SyslogSeverity int
+
+const (
+	SyslogEmerg SyslogSeverity = iota
+	SyslogAlert
+	SyslogCrit
+	SyslogErr
+	SyslogWarning
+	SyslogNotice
+	SyslogInfo
+	SyslogDebug
+)
+
+// SyslogLogger sends every record to the local syslog daemon as one message.
+// There is no file to read or clear, those methods return a NO_FILE Fault
+// like StdoutLogger does
+type SyslogLogger struct {
+	w io.WriteCloser
+}
+
+func (l *SyslogLogger) Write(p []byte) (int, error) {
+	return l.w.Write(p)
+}
+
+func (l *SyslogLogger) WriteLine(s string) (int, error) {
+	return l.Write(JoinLines([]string{s}))
+}
+
+func (l *SyslogLogger) WriteLines(ss []string) (int, error) {
+	return l.Write(JoinLines(ss))
+}
+
+func (l *SyslogLogger) Close() error {
+	return l.w.Close()
+}
+
+func (l *SyslogLogger) ReadLog(offset int64, length int64) (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) ReadTailLog(offset int64, length int64) (string, int64, bool, error) {
+	return "", 0, false, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) ReadTailLines(n int) ([]string, error) {
+	return nil, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) LineCount() (int64, error) {
+	return 0, NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) Stats() LoggerStats {
+	return LoggerStats{}
+}
+
+func (l *SyslogLogger) Sync() error {
+	return nil
+}
+
+func (l *SyslogLogger) ClearCurLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) ClearAllLogFile() error {
+	return NewFault(NO_FILE, "NO_FILE")
+}
+
+func (l *SyslogLogger) ClearOldestBackup() (string, error) {
+	return "", NewFault(NO_FILE, "NO_FILE")
+}
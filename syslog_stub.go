@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package core
+
+import (
+	"errors"
+)
+
+// NewSyslogLogger always fails, there is no syslog on this system
+func NewSyslogLogger(facility SyslogFacility, severity SyslogSeverity, tag string) (*SyslogLogger, error) {
+	return nil, errors.New("syslog is not supported on this system")
+}
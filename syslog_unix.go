@@ -0,0 +1,18 @@
+//go:build !windows && !plan9
+
+package core
+
+import (
+	"log/syslog"
+)
+
+// NewSyslogLogger connects to the local syslog daemon, the records are sent
+// with the given facility and severity and tagged with tag, the program
+// name if tag is empty
+func NewSyslogLogger(facility SyslogFacility, severity SyslogSeverity, tag string) (*SyslogLogger, error) {
+	w, err := syslog.New(syslog.Priority(facility)|syslog.Priority(severity), tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogLogger{w: w}, nil
+}
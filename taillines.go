@@ -0,0 +1,66 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// how many bytes ReadTailLines reads at a time going backward
+const tailChunkSize = 4096
+
+// ReadTailLines returns the last n lines of the current log file, oldest
+// first and without their newline, like tail -n. A last line not ended by a
+// newline is returned too, and a file with fewer than n lines is returned
+// whole
+func (l *FileLogger) ReadTailLines(n int) ([]string, error) {
+	l.rlock()
+	defer l.runlock()
+
+	f, fileLen, err := l.openCurrentRange()
+	if err != nil {
+		return nil, NewFaultWrap(FAILED, "FAILED", err)
+	}
+	defer f.Close()
+
+	return ReadTailLinesAt(f, fileLen, n)
+}
+
+// ReadTailLinesAt reads the last n lines of r, whose size is fileLen, with
+// the ReadTailLines rules
+func ReadTailLinesAt(r io.ReaderAt, fileLen int64, n int) ([]string, error) {
+	if n < 0 {
+		return nil, NewFault(BAD_ARGUMENTS, "BAD_ARGUMENTS")
+	}
+	if n == 0 || fileLen == 0 {
+		return nil, nil
+	}
+
+	//read backward until n+1 newlines are seen, the first one ending the
+	//line before the ones wanted. A newline ending the file ends no line
+	var tail []byte
+	pos := fileLen
+	newlines := 0
+	for pos > 0 && newlines <= n {
+		size := int64(tailChunkSize)
+		if size > pos {
+			size = pos
+		}
+		pos -= size
+		chunk := make([]byte, size, size+int64(len(tail)))
+		if _, err := r.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, NewFaultWrap(FAILED, "FAILED", err)
+		}
+		newlines += bytes.Count(chunk, []byte{'\n'})
+		if pos+size == fileLen && chunk[size-1] == '\n' {
+			newlines--
+		}
+		tail = append(chunk, tail...)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(tail), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// layout of the time in the name of a RotateTimestamp log file, in UTC
+const timestampLayout = "20060102-150405.000"
+
+// return the name of the RotateTimestamp log file created at t
+func (l *FileLogger) timestampLogFile(t time.Time) string {
+	return l.name + "." + t.UTC().Format(timestampLayout)
+}
+
+// return the time in the name of a RotateTimestamp log file found in the
+// log directory, the name must be exactly what timestampLogFile makes
+func (l *FileLogger) fileTimestamp(fileName string) (time.Time, bool) {
+	prefix := filepath.Base(l.name) + "."
+	if !strings.HasPrefix(fileName, prefix) {
+		return time.Time{}, false
+	}
+	s := trimCompressed(fileName[len(prefix):])
+	t, err := time.ParseInLocation(timestampLayout, s, time.UTC)
+	if err != nil || t.Format(timestampLayout) != s {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// return the timestamped log files of the directory entries sorted by the
+// time in their name, oldest first
+func (l *FileLogger) listTimestampLogFiles(entries []os.FileInfo) []logFile {
+	files := make([]logFile, 0)
+	for _, fileInfo := range entries {
+		if t, ok := l.fileTimestamp(fileInfo.Name()); ok {
+			name := l.timestampLogFile(t)
+			name += compressedExt(fileInfo.Name())
+			files = append(files, logFile{name: name,
+				info:  fileInfo,
+				stamp: t})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].stamp.Before(files[j].stamp)
+	})
+	return files
+}
+
+// move to a new timestamped file. The time in its name is always after the
+// one of the current file even if the clock went back, so the order of the
+// names is the order of creation
+func (l *FileLogger) nextTimestampLogFile() {
+	l.curStamp = l.nextStamp()
+	l.curFile = l.timestampLogFile(l.curStamp)
+}
+
+// return the time in the name of the next timestamped file
+func (l *FileLogger) nextStamp() time.Time {
+	stamp := l.clock.Now().UTC().Truncate(time.Millisecond)
+	if !stamp.After(l.curStamp) {
+		stamp = l.curStamp.Add(time.Millisecond)
+	}
+	return stamp
+}
+
+// find the latest timestamped file, by the time in its name, and continue it
+// unless it is full
+func (l *FileLogger) updateLatestTimestampLog() error {
+	files, err := l.listLogFiles()
+	//a compressed file is never the current one
+	if err == nil && len(files) > 0 && !isCompressed(files[len(files)-1].name) {
+		latest := files[len(files)-1]
+		l.curFile = latest.name
+		l.curStamp = latest.stamp
+		l.fileSize = latest.info.Size()
+	}
+	if l.curFile == "" || l.full() {
+		if err == nil && len(files) > 0 && l.curStamp.IsZero() {
+			//keep the names after the ones of the compressed files
+			l.curStamp = files[len(files)-1].stamp
+		}
+		l.nextLogFile()
+		err = l.openFile(true)
+	} else {
+		err = l.openAppend()
+	}
+	if err != nil {
+		l.handleError(err)
+	}
+	return err
+}
+
+// return the most recent timestamped backup, or "" if there is none
+func (l *FileLogger) prevTimestampLogFile() string {
+	files, err := l.listLogFiles()
+	if err != nil {
+		return ""
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if files[i].name != l.curFile {
+			return files[i].name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	core "github.com/menghuitong/fileutils"
+)
+
+// return the names of the log files of l, oldest first
+func logFileNames(t *testing.T, l *core.FileLogger) []string {
+	t.Helper()
+	files, err := l.GetLogFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestTimestampRemovesOldestFirst(t *testing.T) {
+	clock := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	name := filepath.Join(t.TempDir(), "test.log")
+	l := newLogger(t, name, core.WithMaxSize(10), core.WithBackups(3),
+		core.WithRotationStrategy(core.RotateTimestamp), core.WithClock(clock))
+
+	//every file created, in order, the first one created by the logger
+	created := []string{l.GetCurrentLogFile()}
+	for i := 0; i < 5; i++ {
+		clock.step()
+		write(t, l, "123456789\n")
+		created = append(created, l.GetCurrentLogFile())
+		kept := created
+		if len(kept) > 3 {
+			kept = kept[len(kept)-3:]
+		}
+		if got := logFileNames(t, l); !reflect.DeepEqual(got, kept) {
+			t.Fatalf("after %d rotations: files %v, want %v", i+1, got, kept)
+		}
+	}
+	for _, file := range created[:3] {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("%s not removed", file)
+		}
+	}
+}
+
+func TestTimestampOrderByName(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.log")
+	//the modification times are the reverse of the times in the names, as
+	//after a clock skew: the names decide which file is the latest and which
+	//one is the oldest
+	stamps := []string{"20240101-000000.000", "20240101-000001.000", "20240101-000002.000"}
+	for i, stamp := range stamps {
+		file := name + "." + stamp
+		if err := os.WriteFile(file, []byte("12345\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(-time.Duration(i) * time.Hour)
+		os.Chtimes(file, modTime, modTime)
+	}
+	clock := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 3, 0, time.UTC)}
+	l := newLogger(t, name, core.WithMaxSize(10), core.WithBackups(3),
+		core.WithRotationStrategy(core.RotateTimestamp), core.WithClock(clock))
+	if got := l.GetCurrentLogFile(); got != name+"."+stamps[2] {
+		t.Fatalf("current file %s, want the latest name", got)
+	}
+
+	write(t, l, "6789\n")
+	want := []string{name + "." + stamps[1], name + "." + stamps[2], name + ".20240101-000003.000"}
+	if got := logFileNames(t, l); !reflect.DeepEqual(got, want) {
+		t.Errorf("files %v, want %v", got, want)
+	}
+}